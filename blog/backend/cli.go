@@ -0,0 +1,221 @@
+package main
+
+import (
+	"blog/config"
+	"blog/models"
+	"blog/services"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// 命令行用法
+const cliUsage = `用法:
+  go run . import markdown [-author 用户名] [-commit] <目录>
+  go run . import wordpress [-media wp-content/uploads目录] [-commit] <WXR文件>
+  go run . export [-html] [-uploads 上传目录] [-title 站点标题] <输出目录>
+  go run . storage migrate-avatars [-dir 上传目录] [-commit] [-remove]
+`
+
+// 执行命令行子命令
+func runCommand(args []string) error {
+	if len(args) >= 2 && args[0] == "import" && args[1] == "markdown" {
+		return runImportMarkdown(args[2:])
+	}
+	if len(args) >= 2 && args[0] == "import" && args[1] == "wordpress" {
+		return runImportWordPress(args[2:])
+	}
+	if len(args) >= 1 && args[0] == "export" {
+		return runExport(args[1:])
+	}
+	if len(args) >= 2 && args[0] == "storage" && args[1] == "migrate-avatars" {
+		return runMigrateAvatars(args[2:])
+	}
+	return errors.New(cliUsage)
+}
+
+// 从Markdown目录导入文章，默认只输出预览，加 -commit 后写入数据库
+func runImportMarkdown(args []string) error {
+	flags := flag.NewFlagSet("import markdown", flag.ContinueOnError)
+	author := flags.String("author", "admin", "文章作者的用户名")
+	commit := flags.Bool("commit", false, "写入数据库（默认只预览）")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New(cliUsage)
+	}
+
+	files, err := services.ReadMarkdownDir(flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf("读取目录失败: %v", err)
+	}
+
+	config.InitDB()
+	defer config.CloseDB()
+	config.RunMigrations()
+
+	var user models.User
+	if err := config.DB.Where("username = ?", *author).First(&user).Error; err != nil {
+		return fmt.Errorf("用户不存在: %s", *author)
+	}
+
+	report, err := services.ImportMarkdown(files, user.ID, *commit)
+	if err != nil {
+		return err
+	}
+
+	printImportReport(report)
+	if report.DryRun {
+		fmt.Println("\n以上为预览，未写入数据库。确认无误后加 -commit 参数重新执行。")
+	}
+	return nil
+}
+
+// 从WordPress的WXR导出文件导入文章、页面和评论，默认只输出预览，加 -commit 后写入数据库
+func runImportWordPress(args []string) error {
+	flags := flag.NewFlagSet("import wordpress", flag.ContinueOnError)
+	media := flags.String("media", "", "本地的 wp-content/uploads 目录，指定后复制附件并改写链接")
+	commit := flags.Bool("commit", false, "写入数据库（默认只预览）")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New(cliUsage)
+	}
+
+	file, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf("打开文件失败: %v", err)
+	}
+	defer file.Close()
+
+	config.InitDB()
+	defer config.CloseDB()
+	config.RunMigrations()
+	config.InitStorage()
+
+	report, err := services.ImportWordPress(file, services.WordPressImportOptions{
+		MediaDir: *media,
+		Commit:   *commit,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, item := range report.Items {
+		switch item.Action {
+		case services.ImportActionError:
+			fmt.Printf("! %s %s: %s\n", item.File, item.Title, item.Error)
+		case services.ImportActionCreate:
+			fmt.Printf("+ %s (%s) %s\n", item.File, item.Slug, item.Title)
+		default:
+			fmt.Printf("= %s (%s) %s 已存在，跳过\n", item.File, item.Slug, item.Title)
+		}
+	}
+	for _, missing := range report.Missing {
+		fmt.Printf("? 媒体目录中缺少附件: %s\n", missing)
+	}
+
+	fmt.Printf("\n新建文章 %d，跳过 %d，失败 %d；评论 %d，占位用户 %d，标签 %d，分类 %d，附件 %d\n",
+		report.Posts, report.Skipped, report.Failed, report.Comments, report.Users, report.Tags, report.Categories, report.Media)
+	if report.DryRun {
+		fmt.Println("\n以上为预览，未写入数据库。确认无误后加 -commit 参数重新执行。")
+	}
+	return nil
+}
+
+// 输出导入报告
+func printImportReport(report *services.ImportReport) {
+	symbols := map[string]string{
+		services.ImportActionCreate:    "+",
+		services.ImportActionUpdate:    "~",
+		services.ImportActionUnchanged: "=",
+		services.ImportActionError:     "!",
+	}
+
+	for _, item := range report.Items {
+		switch item.Action {
+		case services.ImportActionError:
+			fmt.Printf("%s %s: %s\n", symbols[item.Action], item.File, item.Error)
+		default:
+			fmt.Printf("%s %s (%s) %s\n", symbols[item.Action], item.File, item.Slug, item.Title)
+		}
+		for _, change := range item.Changes {
+			fmt.Printf("    %s: %q -> %q\n", change.Field, change.Old, change.New)
+		}
+	}
+
+	fmt.Printf("\n新建 %d，更新 %d，未变化 %d，失败 %d\n", report.Created, report.Updated, report.Unchanged, report.Failed)
+}
+
+// 导出已发布的文章为Markdown文件，加 -html 时同时生成静态站点
+func runExport(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	html := flags.Bool("html", false, "同时生成静态HTML站点")
+	uploads := flags.String("uploads", config.UploadsDir, "上传文件目录，生成静态站点时复制；为空时不复制")
+	title := flags.String("title", "", "静态站点标题")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New(cliUsage)
+	}
+
+	config.InitDB()
+	defer config.CloseDB()
+	config.InitStorage()
+
+	result, err := services.ExportToDir(flags.Arg(0), services.ExportOptions{
+		HTML:       *html,
+		UploadsDir: *uploads,
+		SiteTitle:  *title,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("已导出 %d 篇文章到 %s\n", result.Posts, flags.Arg(0))
+	if *html {
+		fmt.Printf("静态站点：标签 %d 个，分类 %d 个，上传文件 %d 个\n", result.Tags, result.Categories, result.Uploads)
+	}
+	return nil
+}
+
+// 把本地磁盘上的头像迁移到对象存储（需配置 BLOG_STORAGE=s3），默认只输出预览，加 -commit 后执行
+func runMigrateAvatars(args []string) error {
+	flags := flag.NewFlagSet("storage migrate-avatars", flag.ContinueOnError)
+	dir := flags.String("dir", config.UploadsDir, "原上传目录")
+	commit := flags.Bool("commit", false, "上传文件并改写头像链接（默认只预览）")
+	remove := flags.Bool("remove", false, "上传成功后删除本地文件")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return errors.New(cliUsage)
+	}
+
+	config.InitDB()
+	defer config.CloseDB()
+	config.InitStorage()
+
+	report, err := services.MigrateAvatars(*dir, *commit, *remove)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range report.Items {
+		if item.Error != "" {
+			fmt.Printf("! %s %s: %s\n", item.Username, item.From, item.Error)
+			continue
+		}
+		fmt.Printf("+ %s %s -> %s\n", item.Username, item.From, item.To)
+	}
+
+	fmt.Printf("\n迁移 %d，失败 %d\n", report.Migrated, report.Failed)
+	if report.DryRun {
+		fmt.Println("\n以上为预览，未上传文件。确认无误后加 -commit 参数重新执行。")
+	}
+	return nil
+}
@@ -1,52 +1,100 @@
-package config
-
-import (
-	"blog/models"
-	"log"
-)
-
-// 执行数据库迁移
-func RunMigrations() {
-	// 自动迁移模型到数据库
-	err := DB.AutoMigrate(
-		&models.User{},
-		&models.Post{},
-		&models.Tag{},
-		&models.Comment{},
-		&models.Category{},
-		&models.Favorite{},
-		&models.Notification{},
-	)
-
-	if err != nil {
-		log.Fatalf("数据库迁移失败: %v", err)
-	}
-
-	log.Println("数据库迁移完成")
-
-	// 创建管理员账户（如果不存在）
-	createAdminUser()
-}
-
-// 创建管理员账户
-func createAdminUser() {
-	var count int64
-	DB.Model(&models.User{}).Where("role = ?", "admin").Count(&count)
-
-	if count == 0 {
-		admin := models.User{
-			Username: "admin",
-			Email:    "admin@example.com",
-			Password: "admin123", // 在生产环境中使用更强的密码
-			Role:     "admin",
-			Avatar:   "https://ui-avatars.com/api/?name=Admin&background=random",
-		}
-
-		if err := DB.Create(&admin).Error; err != nil {
-			log.Printf("创建管理员账户失败: %v", err)
-			return
-		}
-
-		log.Println("创建管理员账户成功")
-	}
-}
+package config
+
+import (
+	"blog/models"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// 执行数据库迁移
+func RunMigrations() {
+	// 自动迁移模型到数据库
+	err := DB.AutoMigrate(
+		&models.User{},
+		&models.Post{},
+		&models.Tag{},
+		&models.Comment{},
+		&models.Category{},
+		&models.Favorite{},
+		&models.Notification{},
+		&models.Series{},
+		&models.SeriesPost{},
+		&models.Reaction{},
+		&models.PostReactionCount{},
+		&models.Media{},
+		&models.PostViewDaily{},
+		&models.PostAuthor{},
+		&models.ReviewComment{},
+		&models.PostPreviewLink{},
+		&models.PostTemplate{},
+		&models.Snippet{},
+	)
+
+	if err != nil {
+		log.Fatalf("数据库迁移失败: %v", err)
+	}
+
+	// 文章的 (language, slug) 普通索引已由唯一索引 idx_posts_language_slug 取代
+	if DB.Migrator().HasIndex(&models.Post{}, "idx_post_language_slug") {
+		if err := DB.Migrator().DropIndex(&models.Post{}, "idx_post_language_slug"); err != nil {
+			log.Printf("删除旧索引失败: %v", err)
+		}
+	}
+
+	log.Println("数据库迁移完成")
+
+	// 初始化全文检索
+	setupFullTextSearch()
+
+	// 为已有文章补充字数、阅读时间和目录
+	backfillContentStats()
+
+	// 创建管理员账户（如果不存在）
+	createAdminUser()
+}
+
+// 计算尚未统计过的文章（阅读时间为0且正文非空）的字数、阅读时间和目录
+func backfillContentStats() {
+	var posts []models.Post
+	result := DB.Unscoped().Select("id, content").
+		Where("reading_time = 0 AND content <> ''").
+		FindInBatches(&posts, 200, func(tx *gorm.DB, batch int) error {
+			for _, post := range posts {
+				if err := DB.Unscoped().Model(&models.Post{}).Where("id = ?", post.ID).
+					UpdateColumns(models.ContentStatsColumns(post.Content)).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+
+	if result.Error != nil {
+		log.Printf("统计文章字数失败: %v", result.Error)
+	} else if result.RowsAffected > 0 {
+		log.Printf("已为 %d 篇文章统计字数和阅读时间", result.RowsAffected)
+	}
+}
+
+// 创建管理员账户
+func createAdminUser() {
+	var count int64
+	DB.Model(&models.User{}).Where("role = ?", "admin").Count(&count)
+
+	if count == 0 {
+		admin := models.User{
+			Username: "admin",
+			Email:    "admin@example.com",
+			Password: "admin123", // 在生产环境中使用更强的密码
+			Role:     "admin",
+			Avatar:   "https://ui-avatars.com/api/?name=Admin&background=random",
+		}
+
+		if err := DB.Create(&admin).Error; err != nil {
+			log.Printf("创建管理员账户失败: %v", err)
+			return
+		}
+
+		log.Println("创建管理员账户成功")
+	}
+}
@@ -0,0 +1,121 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"os"
+)
+
+var (
+	// 全文检索使用的PostgreSQL文本搜索配置
+	SearchConfig = "simple"
+	// 没有可用的中文分词配置时，使用二元分词（n-gram）作为后备方案
+	SearchNgram = true
+)
+
+// 初始化全文检索：检测分词配置，创建tsvector列、GIN索引和维护触发器
+func setupFullTextSearch() {
+	detectSearchConfig()
+
+	// 预处理函数：分词模式下原样返回，n-gram模式下把连续的中日韩字符（包括韩文）拆成二元词组
+	textBody := `
+			SELECT coalesce(src, '')
+		`
+	if SearchNgram {
+		textBody = `
+			SELECT regexp_replace(coalesce(src, ''), '[\u1100-\u11ff\u3040-\u30ff\u3130-\u318f\u3400-\u9fff\uac00-\ud7af\uf900-\ufaff]+', ' ', 'g') || ' ' ||
+				coalesce((
+					SELECT string_agg(substr(m[1], i, 2), ' ')
+					FROM regexp_matches(coalesce(src, ''), '([\u1100-\u11ff\u3040-\u30ff\u3130-\u318f\u3400-\u9fff\uac00-\ud7af\uf900-\ufaff]+)', 'g') AS m,
+						generate_series(1, greatest(char_length(m[1]) - 1, 1)) AS i
+				), '')
+		`
+	}
+	textFunc := `
+		CREATE OR REPLACE FUNCTION blog_search_text(src text) RETURNS text AS $$` + textBody + `$$ LANGUAGE sql IMMUTABLE`
+
+	// 预处理函数的定义发生变化时（如切换分词模式、调整字符范围），已有的索引列需要重建
+	var oldBody string
+	DB.Raw("SELECT prosrc FROM pg_proc WHERE proname = 'blog_search_text'").Scan(&oldBody)
+	rebuild := oldBody != "" && oldBody != textBody
+
+	statements := []string{
+		textFunc,
+
+		// 文章：标题、摘要、正文按权重A/B/C组合
+		`ALTER TABLE posts ADD COLUMN IF NOT EXISTS search_vector tsvector`,
+		`CREATE INDEX IF NOT EXISTS idx_posts_search_vector ON posts USING GIN (search_vector)`,
+		fmt.Sprintf(`
+		CREATE OR REPLACE FUNCTION posts_search_vector_update() RETURNS trigger AS $$
+		BEGIN
+			NEW.search_vector :=
+				setweight(to_tsvector('%[1]s', blog_search_text(NEW.title)), 'A') ||
+				setweight(to_tsvector('%[1]s', blog_search_text(NEW.summary)), 'B') ||
+				setweight(to_tsvector('%[1]s', blog_search_text(NEW.content)), 'C');
+			RETURN NEW;
+		END
+		$$ LANGUAGE plpgsql`, SearchConfig),
+		`DROP TRIGGER IF EXISTS posts_search_vector_trigger ON posts`,
+		`CREATE TRIGGER posts_search_vector_trigger
+			BEFORE INSERT OR UPDATE OF title, summary, content ON posts
+			FOR EACH ROW EXECUTE FUNCTION posts_search_vector_update()`,
+
+		// 评论：只索引内容
+		`ALTER TABLE comments ADD COLUMN IF NOT EXISTS search_vector tsvector`,
+		`CREATE INDEX IF NOT EXISTS idx_comments_search_vector ON comments USING GIN (search_vector)`,
+		fmt.Sprintf(`
+		CREATE OR REPLACE FUNCTION comments_search_vector_update() RETURNS trigger AS $$
+		BEGIN
+			NEW.search_vector := to_tsvector('%[1]s', blog_search_text(NEW.content));
+			RETURN NEW;
+		END
+		$$ LANGUAGE plpgsql`, SearchConfig),
+		`DROP TRIGGER IF EXISTS comments_search_vector_trigger ON comments`,
+		`CREATE TRIGGER comments_search_vector_trigger
+			BEFORE INSERT OR UPDATE OF content ON comments
+			FOR EACH ROW EXECUTE FUNCTION comments_search_vector_update()`,
+	}
+	if rebuild {
+		statements = append(statements,
+			`UPDATE posts SET search_vector = NULL`,
+			`UPDATE comments SET search_vector = NULL`,
+		)
+	}
+	// 为已有数据补齐索引列（切换分词配置后需手动将search_vector置空以重建）
+	statements = append(statements,
+		`UPDATE posts SET title = title WHERE search_vector IS NULL`,
+		`UPDATE comments SET content = content WHERE search_vector IS NULL`,
+	)
+
+	for _, stmt := range statements {
+		if err := DB.Exec(stmt).Error; err != nil {
+			log.Fatalf("初始化全文检索失败: %v", err)
+		}
+	}
+
+	if rebuild {
+		log.Println("检索预处理函数已更新，已重建全文索引")
+	}
+	log.Printf("全文检索初始化完成（配置: %s, n-gram: %v）", SearchConfig, SearchNgram)
+}
+
+// 检测可用的文本搜索配置：优先使用环境变量指定的配置，其次是zhparser等中文分词扩展提供的chinese配置
+func detectSearchConfig() {
+	candidates := []string{"chinese"}
+	if name := os.Getenv("BLOG_SEARCH_CONFIG"); name != "" {
+		candidates = append([]string{name}, candidates...)
+	}
+
+	for _, name := range candidates {
+		var count int64
+		DB.Raw("SELECT COUNT(*) FROM pg_ts_config WHERE cfgname = ?", name).Scan(&count)
+		if count > 0 {
+			SearchConfig = name
+			SearchNgram = false
+			return
+		}
+	}
+
+	SearchConfig = "simple"
+	SearchNgram = true
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"blog/storage"
+	"crypto/rand"
+	"log"
+	"os"
+)
+
+// 上传文件的存储后端
+var Storage storage.Storage
+
+// 本地存储目录及对外访问的链接前缀
+const (
+	UploadsDir = "uploads"
+	UploadsURL = "/uploads"
+)
+
+// 初始化文件存储，BLOG_STORAGE=s3 时使用S3兼容对象存储，否则使用本地磁盘
+func InitStorage() {
+	switch backend := os.Getenv("BLOG_STORAGE"); backend {
+	case "", "local":
+		secret := []byte(os.Getenv("BLOG_STORAGE_SECRET"))
+		if len(secret) == 0 {
+			// 未配置时使用随机密钥：签名链接在重启后失效，多实例之间也不能通用
+			secret = make([]byte, 32)
+			if _, err := rand.Read(secret); err != nil {
+				log.Fatalf("生成存储签名密钥失败: %v", err)
+			}
+			log.Println("警告: 未配置 BLOG_STORAGE_SECRET，本地存储使用随机签名密钥，签名链接在重启后失效且不能跨实例使用")
+		}
+		Storage = storage.NewLocal(UploadsDir, UploadsURL, secret)
+		log.Println("使用本地文件存储")
+	case "s3":
+		s3, err := storage.NewS3(storage.S3Config{
+			Endpoint:  os.Getenv("BLOG_S3_ENDPOINT"),
+			Region:    os.Getenv("BLOG_S3_REGION"),
+			Bucket:    os.Getenv("BLOG_S3_BUCKET"),
+			AccessKey: os.Getenv("BLOG_S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("BLOG_S3_SECRET_KEY"),
+			PublicURL: os.Getenv("BLOG_S3_PUBLIC_URL"),
+			PathStyle: os.Getenv("BLOG_S3_PATH_STYLE") == "true",
+			ProxyURL:  UploadsURL,
+		})
+		if err != nil {
+			log.Fatalf("初始化对象存储失败: %v", err)
+		}
+		Storage = s3
+		log.Println("使用S3兼容对象存储")
+	default:
+		log.Fatalf("不支持的存储类型: %s", backend)
+	}
+}
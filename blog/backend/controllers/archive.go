@@ -0,0 +1,127 @@
+package controllers
+
+import (
+	"blog/config"
+	"blog/models"
+	"blog/utils"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 归档中某个月的文章数
+type archiveMonth struct {
+	Month int   `json:"month"`
+	Count int64 `json:"count"`
+}
+
+// 归档中某一年的文章数及各月明细
+type archiveYear struct {
+	Year   int            `json:"year"`
+	Count  int64          `json:"count"`
+	Months []archiveMonth `json:"months"`
+}
+
+// 获取文章归档：按年、月统计已发布且公开列出的文章数量，按时间倒序排列
+// 年月按服务器本地时区计算，与按年月查询文章时的时间范围一致
+func GetArchive(c *gin.Context) {
+	var rows []struct {
+		Year  int
+		Month int
+		Count int64
+	}
+	zone, _ := utils.LocalZone()
+	if err := config.DB.Model(&models.Post{}).
+		Scopes(scopeListedPosts).
+		Select("EXTRACT(YEAR FROM posts.created_at AT TIME ZONE ?)::int AS year, EXTRACT(MONTH FROM posts.created_at AT TIME ZONE ?)::int AS month, COUNT(*) AS count", zone, zone).
+		Group("year, month").
+		Order("year DESC, month DESC").
+		Scan(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取文章归档失败"})
+		return
+	}
+
+	years := make([]archiveYear, 0)
+	for _, row := range rows {
+		if len(years) == 0 || years[len(years)-1].Year != row.Year {
+			years = append(years, archiveYear{Year: row.Year, Months: []archiveMonth{}})
+		}
+		year := &years[len(years)-1]
+		year.Count += row.Count
+		year.Months = append(year.Months, archiveMonth{Month: row.Month, Count: row.Count})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": years,
+	})
+}
+
+// 分页获取某年或某月发布的文章
+func GetArchivePosts(c *gin.Context) {
+	from, to, err := archivePeriod(c.Param("year"), c.Param("month"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 50 {
+		pageSize = 10
+	}
+
+	query := scopeDateRange(config.DB.Model(&models.Post{}).Scopes(scopeListedPosts), from, to)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取文章失败"})
+		return
+	}
+
+	var posts []models.Post
+	if err := query.Preload("User").Preload("Tags").Preload("Categories").
+		Order("posts.created_at DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&posts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取文章失败"})
+		return
+	}
+
+	attachReactions(c, postPointers(posts))
+	redactProtectedPosts(c, postPointers(posts))
+	expandPostShortcodes(c, postPointers(posts))
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  posts,
+		"total": total,
+		"page":  page,
+		"size":  pageSize,
+	})
+}
+
+// 把年份和月份（可为空）转换为时间范围 [from, to)，使用服务器本地时区
+func archivePeriod(yearParam, monthParam string) (time.Time, time.Time, error) {
+	_, loc := utils.LocalZone()
+	year, err := strconv.Atoi(yearParam)
+	if err != nil || year < 1970 || year > 9999 {
+		return time.Time{}, time.Time{}, errors.New("无效的年份")
+	}
+	if monthParam == "" {
+		from := time.Date(year, time.January, 1, 0, 0, 0, 0, loc)
+		return from, from.AddDate(1, 0, 0), nil
+	}
+
+	month, err := strconv.Atoi(monthParam)
+	if err != nil || month < 1 || month > 12 {
+		return time.Time{}, time.Time{}, errors.New("无效的月份")
+	}
+	from := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, loc)
+	return from, from.AddDate(0, 1, 0), nil
+}
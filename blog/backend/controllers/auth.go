@@ -1,114 +1,114 @@
-package controllers
-
-import (
-	"blog/config"
-	"blog/models"
-	"blog/utils"
-	"net/http"
-
-	"github.com/gin-gonic/gin"
-)
-
-// 用户注册请求
-type RegisterRequest struct {
-	Username string `json:"username" binding:"required,min=3,max=50"`
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=6"`
-}
-
-// 用户登录请求
-type LoginRequest struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required"`
-}
-
-// 用户注册
-func Register(c *gin.Context) {
-	var req RegisterRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "验证失败: " + err.Error()})
-		return
-	}
-
-	// 检查邮箱是否已存在
-	var existingUser models.User
-	if result := config.DB.Where("email = ?", req.Email).First(&existingUser); result.Error == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "该邮箱已被注册"})
-		return
-	}
-
-	// 检查用户名是否已存在
-	if result := config.DB.Where("username = ?", req.Username).First(&existingUser); result.Error == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "该用户名已被使用"})
-		return
-	}
-
-	// 创建新用户
-	user := models.User{
-		Username: req.Username,
-		Email:    req.Email,
-		Password: req.Password,
-		Role:     "user", // 默认角色
-	}
-
-	if result := config.DB.Create(&user); result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建用户失败: " + result.Error.Error()})
-		return
-	}
-
-	// 生成JWT令牌
-	token, err := utils.GenerateToken(user.ID, user.Username, user.Role)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成令牌失败: " + err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusCreated, gin.H{
-		"token": token,
-		"user": gin.H{
-			"id":       user.ID,
-			"username": user.Username,
-			"email":    user.Email,
-			"role":     user.Role,
-		},
-	})
-}
-
-// 用户登录
-func Login(c *gin.Context) {
-	var req LoginRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "验证失败: " + err.Error()})
-		return
-	}
-
-	// 查找用户
-	var user models.User
-	if result := config.DB.Where("email = ?", req.Email).First(&user); result.Error != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "邮箱或密码不正确"})
-		return
-	}
-
-	// 验证密码
-	if !user.CheckPassword(req.Password) {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "邮箱或密码不正确"})
-		return
-	}
-
-	// 生成JWT令牌
-	token, err := utils.GenerateToken(user.ID, user.Username, user.Role)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成令牌失败: " + err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"token": token,
-		"user": gin.H{
-			"id":       user.ID,
-			"username": user.Username,
-			"email":    user.Email,
-			"role":     user.Role,
-		},
-	})
-}
+package controllers
+
+import (
+	"blog/config"
+	"blog/models"
+	"blog/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 用户注册请求
+type RegisterRequest struct {
+	Username string `json:"username" binding:"required,min=3,max=50"`
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required,min=6"`
+}
+
+// 用户登录请求
+type LoginRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
+
+// 用户注册
+func Register(c *gin.Context) {
+	var req RegisterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "验证失败: " + err.Error()})
+		return
+	}
+
+	// 检查邮箱是否已存在
+	var existingUser models.User
+	if result := config.DB.Where("email = ?", req.Email).First(&existingUser); result.Error == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "该邮箱已被注册"})
+		return
+	}
+
+	// 检查用户名是否已存在
+	if result := config.DB.Where("username = ?", req.Username).First(&existingUser); result.Error == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "该用户名已被使用"})
+		return
+	}
+
+	// 创建新用户
+	user := models.User{
+		Username: req.Username,
+		Email:    req.Email,
+		Password: req.Password,
+		Role:     "user", // 默认角色
+	}
+
+	if result := config.DB.Create(&user); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建用户失败: " + result.Error.Error()})
+		return
+	}
+
+	// 生成JWT令牌
+	token, err := utils.GenerateToken(user.ID, user.Username, user.Role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成令牌失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"token": token,
+		"user": gin.H{
+			"id":       user.ID,
+			"username": user.Username,
+			"email":    user.Email,
+			"role":     user.Role,
+		},
+	})
+}
+
+// 用户登录
+func Login(c *gin.Context) {
+	var req LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "验证失败: " + err.Error()})
+		return
+	}
+
+	// 查找用户
+	var user models.User
+	if result := config.DB.Where("email = ?", req.Email).First(&user); result.Error != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "邮箱或密码不正确"})
+		return
+	}
+
+	// 验证密码
+	if !user.CheckPassword(req.Password) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "邮箱或密码不正确"})
+		return
+	}
+
+	// 生成JWT令牌
+	token, err := utils.GenerateToken(user.ID, user.Username, user.Role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成令牌失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"token": token,
+		"user": gin.H{
+			"id":       user.ID,
+			"username": user.Username,
+			"email":    user.Email,
+			"role":     user.Role,
+		},
+	})
+}
@@ -0,0 +1,350 @@
+package controllers
+
+import (
+	"blog/config"
+	"blog/models"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 邀请共同作者请求，userId 和 username 二选一
+type InviteAuthorRequest struct {
+	UserID   uint   `json:"userId"`
+	Username string `json:"username"`
+	Role     string `json:"role" binding:"required"` // coauthor, reviewer
+}
+
+// 修改作者角色请求
+type UpdateAuthorRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// 作者角色的中文名称，用于通知内容
+var authorRoleNames = map[string]string{
+	models.AuthorRoleCoauthor: "共同作者",
+	models.AuthorRoleReviewer: "审阅者",
+}
+
+// 用户已接受邀请的文章ID子查询，roles为空时不限角色
+func acceptedAuthorPostIDs(userID uint, roles ...string) *gorm.DB {
+	query := config.DB.Model(&models.PostAuthor{}).Select("post_id").
+		Where("user_id = ? AND status = ?", userID, models.AuthorStatusAccepted)
+	if len(roles) > 0 {
+		query = query.Where("role IN ?", roles)
+	}
+	return query
+}
+
+// 用户在文章中的角色：所有者、已接受邀请的共同作者或审阅者，都不是时返回空字符串
+func postAuthorRole(userID uint, post *models.Post) string {
+	if userID == post.UserID {
+		return models.AuthorRoleOwner
+	}
+	var author models.PostAuthor
+	if err := config.DB.Select("role").
+		Where("post_id = ? AND user_id = ? AND status = ?", post.ID, userID, models.AuthorStatusAccepted).
+		First(&author).Error; err != nil {
+		return ""
+	}
+	return author.Role
+}
+
+// 当前用户是否为管理员或文章的任一作者（含审阅者），可以查看未发布和私密的文章
+func isPostAuthor(c *gin.Context, post *models.Post) bool {
+	if isPostOwnerOrAdmin(c, post) {
+		return true
+	}
+	user := currentUser(c)
+	return user != nil && postAuthorRole(user.ID, post) != ""
+}
+
+// 当前用户能否编辑文章：管理员、所有者或共同作者
+func canEditPost(c *gin.Context, post *models.Post) bool {
+	if isPostOwnerOrAdmin(c, post) {
+		return true
+	}
+	user := currentUser(c)
+	return user != nil && postAuthorRole(user.ID, post) == models.AuthorRoleCoauthor
+}
+
+// 为文章附加作者列表：所有者在前，其后是已接受邀请的共同作者和审阅者
+func attachAuthors(posts []*models.Post) {
+	if len(posts) == 0 {
+		return
+	}
+
+	postIDs := make([]uint, 0, len(posts))
+	var ownerIDs []uint
+	for _, post := range posts {
+		postIDs = append(postIDs, post.ID)
+		if post.User.ID == 0 {
+			ownerIDs = append(ownerIDs, post.UserID)
+		}
+	}
+
+	var authors []models.PostAuthor
+	config.DB.Preload("User").
+		Where("post_id IN ? AND status = ?", postIDs, models.AuthorStatusAccepted).
+		Order("created_at ASC").
+		Find(&authors)
+	byPost := make(map[uint][]models.PostAuthor)
+	for _, author := range authors {
+		byPost[author.PostID] = append(byPost[author.PostID], author)
+	}
+
+	// 缓存命中的文章没有加载所有者信息
+	owners := make(map[uint]models.User)
+	if len(ownerIDs) > 0 {
+		var users []models.User
+		config.DB.Where("id IN ?", ownerIDs).Find(&users)
+		for _, user := range users {
+			owners[user.ID] = user
+		}
+	}
+
+	for _, post := range posts {
+		owner := post.User
+		if owner.ID == 0 {
+			owner = owners[post.UserID]
+		}
+		post.Authors = append([]models.PostAuthor{{
+			PostID:    post.ID,
+			UserID:    post.UserID,
+			User:      owner,
+			Role:      models.AuthorRoleOwner,
+			Status:    models.AuthorStatusAccepted,
+			CreatedAt: post.CreatedAt,
+		}}, byPost[post.ID]...)
+	}
+}
+
+// 获取文章的作者列表（作者和管理员），包括待接受和已拒绝的邀请
+func GetPostAuthors(c *gin.Context) {
+	var post models.Post
+	if err := config.DB.Preload("User").First(&post, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "文章不存在"})
+		return
+	}
+	if !isPostAuthor(c, &post) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权查看此文章的作者"})
+		return
+	}
+
+	var invitations []models.PostAuthor
+	config.DB.Preload("User").
+		Where("post_id = ? AND status <> ?", post.ID, models.AuthorStatusAccepted).
+		Order("created_at ASC").
+		Find(&invitations)
+
+	attachAuthors([]*models.Post{&post})
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":        post.Authors,
+		"invitations": invitations,
+	})
+}
+
+// 邀请用户成为文章的共同作者或审阅者（所有者和管理员），被邀请者会收到通知
+// 已拒绝的邀请可以重新发出
+func InviteAuthor(c *gin.Context) {
+	var req InviteAuthorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "验证失败: " + err.Error()})
+		return
+	}
+	if !models.IsInvitableAuthorRole(req.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "角色只能为 coauthor 或 reviewer"})
+		return
+	}
+
+	var post models.Post
+	if err := config.DB.First(&post, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "文章不存在"})
+		return
+	}
+	if !isPostOwnerOrAdmin(c, &post) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "只有文章所有者可以邀请作者"})
+		return
+	}
+
+	var invitee models.User
+	query := config.DB.Select("id, username")
+	switch {
+	case req.UserID != 0:
+		query = query.Where("id = ?", req.UserID)
+	case req.Username != "":
+		query = query.Where("username = ?", req.Username)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请指定被邀请的用户"})
+		return
+	}
+	if err := query.First(&invitee).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+		return
+	}
+	if invitee.ID == post.UserID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "不能邀请文章所有者"})
+		return
+	}
+
+	inviter := currentUser(c)
+	var author models.PostAuthor
+	err := config.DB.Where("post_id = ? AND user_id = ?", post.ID, invitee.ID).First(&author).Error
+	switch {
+	case err == nil && author.Status != models.AuthorStatusDeclined:
+		c.JSON(http.StatusConflict, gin.H{"error": "该用户已是作者或已被邀请"})
+		return
+	case err == nil:
+		author.Role = req.Role
+		author.Status = models.AuthorStatusPending
+		author.InvitedByID = inviter.ID
+		err = config.DB.Save(&author).Error
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		author = models.PostAuthor{
+			PostID:      post.ID,
+			UserID:      invitee.ID,
+			Role:        req.Role,
+			Status:      models.AuthorStatusPending,
+			InvitedByID: inviter.ID,
+		}
+		err = config.DB.Create(&author).Error
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "邀请作者失败"})
+		return
+	}
+
+	content := fmt.Sprintf("%s 邀请你成为文章《%s》的%s", inviter.Username, post.Title, authorRoleNames[req.Role])
+	CreateNotification(invitee.ID, models.NotificationTypeAuthor, content, &inviter.ID, &post.ID, nil, "/user/invitations")
+
+	author.User = invitee
+	c.JSON(http.StatusCreated, gin.H{"message": "邀请已发送", "data": author})
+}
+
+// 修改作者角色（所有者和管理员）
+func UpdatePostAuthor(c *gin.Context) {
+	var req UpdateAuthorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "验证失败: " + err.Error()})
+		return
+	}
+	if !models.IsInvitableAuthorRole(req.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "角色只能为 coauthor 或 reviewer"})
+		return
+	}
+
+	post, author, ok := findPostAuthor(c)
+	if !ok {
+		return
+	}
+	if !isPostOwnerOrAdmin(c, &post) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "只有文章所有者可以修改作者角色"})
+		return
+	}
+
+	if err := config.DB.Model(&author).Update("role", req.Role).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "修改作者角色失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "作者角色已修改", "data": author})
+}
+
+// 移除作者或撤回邀请（所有者和管理员），作者本人也可以退出
+func RemovePostAuthor(c *gin.Context) {
+	post, author, ok := findPostAuthor(c)
+	if !ok {
+		return
+	}
+	user := currentUser(c)
+	if !isPostOwnerOrAdmin(c, &post) && user.ID != author.UserID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权移除此作者"})
+		return
+	}
+
+	if err := config.DB.Delete(&author).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "移除作者失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "作者已移除"})
+}
+
+// 获取当前用户待处理的作者邀请
+func GetMyInvitations(c *gin.Context) {
+	user := currentUser(c)
+
+	var invitations []models.PostAuthor
+	if err := config.DB.Preload("Post", func(db *gorm.DB) *gorm.DB {
+		return db.Select("id, title, status, user_id").Preload("User")
+	}).
+		Joins("JOIN posts ON posts.id = post_authors.post_id AND posts.deleted_at IS NULL").
+		Where("post_authors.user_id = ? AND post_authors.status = ?", user.ID, models.AuthorStatusPending).
+		Order("post_authors.updated_at DESC").
+		Find(&invitations).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取邀请失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": invitations})
+}
+
+// 接受作者邀请
+func AcceptInvitation(c *gin.Context) {
+	respondInvitation(c, models.AuthorStatusAccepted)
+}
+
+// 拒绝作者邀请
+func DeclineInvitation(c *gin.Context) {
+	respondInvitation(c, models.AuthorStatusDeclined)
+}
+
+// 处理邀请并通知邀请人
+func respondInvitation(c *gin.Context, status string) {
+	user := currentUser(c)
+
+	var author models.PostAuthor
+	if err := config.DB.Where("id = ? AND user_id = ? AND status = ?", c.Param("id"), user.ID, models.AuthorStatusPending).
+		First(&author).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "邀请不存在"})
+		return
+	}
+	var post models.Post
+	if err := config.DB.Select("id, title, user_id").First(&post, author.PostID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "文章不存在"})
+		return
+	}
+
+	if err := config.DB.Model(&author).Update("status", status).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "处理邀请失败"})
+		return
+	}
+
+	action, message := "接受", "已接受邀请"
+	if status == models.AuthorStatusDeclined {
+		action, message = "拒绝", "已拒绝邀请"
+	}
+	content := fmt.Sprintf("%s %s了成为文章《%s》%s的邀请", user.Username, action, post.Title, authorRoleNames[author.Role])
+	CreateNotification(author.InvitedByID, models.NotificationTypeAuthor, content, &user.ID, &post.ID, nil, fmt.Sprintf("/posts/%d", post.ID))
+
+	c.JSON(http.StatusOK, gin.H{"message": message, "data": author})
+}
+
+// 按路由参数 id 和 userId 查找文章及其作者记录，找不到时写入错误响应
+func findPostAuthor(c *gin.Context) (models.Post, models.PostAuthor, bool) {
+	var post models.Post
+	var author models.PostAuthor
+	if err := config.DB.First(&post, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "文章不存在"})
+		return post, author, false
+	}
+	if err := config.DB.Where("post_id = ? AND user_id = ?", post.ID, c.Param("userId")).First(&author).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "作者不存在"})
+		return post, author, false
+	}
+	return post, author, true
+}
@@ -0,0 +1,234 @@
+package controllers
+
+import (
+	"blog/config"
+	"blog/models"
+	"blog/services"
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 批量操作的最大文章数
+const maxBulkPosts = 100
+
+// 批量操作请求
+type BulkPostsRequest struct {
+	IDs         []uint   `json:"ids" binding:"required,min=1"`
+	Action      string   `json:"action" binding:"required,oneof=publish unpublish delete restore add_tags remove_tags set_category change_author"`
+	Tags        []string `json:"tags"`        // add_tags、remove_tags 使用
+	CategoryIDs []uint   `json:"categoryIds"` // set_category 使用，传空数组时清空分类
+	AuthorID    uint     `json:"authorId"`    // change_author 使用，仅管理员可用
+	Atomic      bool     `json:"atomic"`      // 为true时任意一篇失败则全部回滚
+}
+
+// 单篇文章的操作结果
+type BulkPostResult struct {
+	ID      uint   `json:"id"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// 批量操作文章
+func BulkPosts(c *gin.Context) {
+	var req BulkPostsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "验证失败: " + err.Error()})
+		return
+	}
+	if len(req.IDs) > maxBulkPosts {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "单次最多操作" + strconv.Itoa(maxBulkPosts) + "篇文章"})
+		return
+	}
+
+	user, _ := c.Get("user")
+	userModel := user.(models.User)
+
+	// 校验操作参数
+	var categories []models.Category
+	switch req.Action {
+	case "add_tags", "remove_tags":
+		if len(req.Tags) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "请指定标签"})
+			return
+		}
+	case "set_category":
+		if req.CategoryIDs == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "请指定分类"})
+			return
+		}
+		var err error
+		if categories, err = findCategoriesByIDs(req.CategoryIDs); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	case "change_author":
+		if userModel.Role != "admin" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "只有管理员可以修改文章作者"})
+			return
+		}
+		var author models.User
+		if err := config.DB.Select("id").First(&author, req.AuthorID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "目标作者不存在"})
+			return
+		}
+	}
+
+	// 恢复操作针对已删除的文章，其余操作只针对未删除的文章
+	var posts []models.Post
+	query := config.DB.Where("id IN ?", req.IDs)
+	if req.Action == "restore" {
+		query = config.DB.Unscoped().Where("id IN ? AND deleted_at IS NOT NULL", req.IDs)
+	}
+	query.Find(&posts)
+	postsByID := make(map[uint]*models.Post, len(posts))
+	for i := range posts {
+		postsByID[posts[i].ID] = &posts[i]
+	}
+
+	results := make([]BulkPostResult, 0, len(req.IDs))
+	var changed []uint
+	failed := 0
+
+	tx := config.DB.Begin()
+
+	// 标签只需查找或创建一次
+	var tags []models.Tag
+	if req.Action == "add_tags" || req.Action == "remove_tags" {
+		var err error
+		if req.Action == "add_tags" {
+			tags, err = services.FindOrCreateTags(tx, req.Tags)
+		} else {
+			err = tx.Where("name IN ?", req.Tags).Find(&tags).Error
+		}
+		if err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "处理标签失败: " + err.Error()})
+			return
+		}
+	}
+
+	seen := make(map[uint]bool, len(req.IDs))
+	for _, id := range req.IDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		result := BulkPostResult{ID: id}
+		post, exists := postsByID[id]
+		switch {
+		case !exists:
+			result.Error = "文章不存在"
+		case !canEditPost(c, post):
+			result.Error = "无权操作此文章"
+		case (req.Action == "delete" || req.Action == "restore") && !isPostOwnerOrAdmin(c, post):
+			// 与单篇删除一致，共同作者只能编辑，不能删除或恢复
+			result.Error = "无权删除或恢复此文章"
+		case req.Action == "publish" || req.Action == "unpublish":
+			// 状态变化与单篇更新遵循相同的审核规则
+			status := models.PostStatusPublished
+			if req.Action == "unpublish" {
+				status = models.PostStatusDraft
+			}
+			if err := checkStatusChange(c, post, status); err != nil {
+				result.Error = err.Error()
+				break
+			}
+			fallthrough
+		default:
+			// 每篇文章在独立的保存点中执行，失败时只回滚该篇
+			err := tx.Transaction(func(itemTx *gorm.DB) error {
+				return applyBulkAction(itemTx, post, &req, tags, categories)
+			})
+			if err != nil {
+				result.Error = err.Error()
+			} else {
+				result.Success = true
+				changed = append(changed, id)
+			}
+		}
+
+		if !result.Success {
+			failed++
+		}
+		results = append(results, result)
+	}
+
+	if req.Atomic && failed > 0 {
+		tx.Rollback()
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "部分文章操作失败，已全部回滚",
+			"results": results,
+		})
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "批量操作失败: " + err.Error()})
+		return
+	}
+
+	// 统一清理缓存
+	invalidatePostCaches(changed)
+
+	c.JSON(http.StatusOK, gin.H{
+		"action":    req.Action,
+		"succeeded": len(changed),
+		"failed":    failed,
+		"results":   results,
+	})
+}
+
+// 对单篇文章执行批量操作
+func applyBulkAction(tx *gorm.DB, post *models.Post, req *BulkPostsRequest, tags []models.Tag, categories []models.Category) error {
+	switch req.Action {
+	case "publish":
+		return tx.Model(post).Update("status", "published").Error
+	case "unpublish":
+		return tx.Model(post).Update("status", "draft").Error
+	case "delete":
+		return tx.Delete(post).Error
+	case "restore":
+		if err := checkSlugAvailable(post.Slug, post.Language, post.ID); err != nil {
+			return err
+		}
+		err := tx.Unscoped().Model(post).Update("deleted_at", nil).Error
+		if isSlugConflict(err) {
+			return errSlugConflict
+		}
+		return err
+	case "add_tags":
+		return tx.Model(post).Association("Tags").Append(tags)
+	case "remove_tags":
+		if len(tags) == 0 {
+			return nil
+		}
+		return tx.Model(post).Association("Tags").Delete(tags)
+	case "set_category":
+		if len(categories) == 0 {
+			return tx.Model(post).Association("Categories").Clear()
+		}
+		return tx.Model(post).Association("Categories").Replace(categories)
+	case "change_author":
+		return tx.Model(post).Update("user_id", req.AuthorID).Error
+	}
+	return errors.New("不支持的操作")
+}
+
+// 批量删除文章详情缓存
+func invalidatePostCaches(postIDs []uint) {
+	if len(postIDs) == 0 {
+		return
+	}
+
+	keys := make([]string, 0, len(postIDs))
+	for _, id := range postIDs {
+		keys = append(keys, services.PostCacheKey(id))
+	}
+	config.Redis.Del(context.Background(), keys...)
+}
@@ -1,200 +1,271 @@
-package controllers
-
-import (
-	"blog/config"
-	"blog/models"
-	"net/http"
-	"strconv"
-
-	"github.com/gin-gonic/gin"
-)
-
-// 获取所有分类
-func GetCategories(c *gin.Context) {
-	var categories []models.Category
-
-	// 查询所有分类
-	if err := config.DB.Order("name ASC").Find(&categories).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取分类列表失败"})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"data": categories,
-	})
-}
-
-// 获取单个分类及其文章
-func GetCategory(c *gin.Context) {
-	categoryID := c.Param("id")
-	if categoryID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "分类ID不能为空"})
-		return
-	}
-
-	var category models.Category
-	if err := config.DB.First(&category, categoryID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "分类不存在"})
-		return
-	}
-
-	// 获取分页参数
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
-	if page < 1 {
-		page = 1
-	}
-	if pageSize < 1 || pageSize > 50 {
-		pageSize = 10
-	}
-	offset := (page - 1) * pageSize
-
-	// 查询分类下的文章
-	var posts []models.Post
-	var total int64
-
-	query := config.DB.Model(&models.Post{}).
-		Joins("JOIN post_categories ON posts.id = post_categories.post_id").
-		Where("post_categories.category_id = ? AND posts.status = ?", category.ID, "published")
-
-	// 获取总数
-	query.Count(&total)
-
-	// 获取文章列表
-	query.Preload("User").Preload("Tags").
-		Order("posts.created_at DESC").
-		Offset(offset).Limit(pageSize).
-		Find(&posts)
-
-	c.JSON(http.StatusOK, gin.H{
-		"category": category,
-		"posts": gin.H{
-			"data":  posts,
-			"total": total,
-			"page":  page,
-			"size":  pageSize,
-		},
-	})
-}
-
-// 添加分类（管理员）
-func AddCategory(c *gin.Context) {
-	// 验证请求
-	var req struct {
-		Name        string `json:"name" binding:"required"`
-		Description string `json:"description"`
-	}
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "验证失败: " + err.Error()})
-		return
-	}
-
-	// 检查分类名是否已存在
-	var existingCategory models.Category
-	if result := config.DB.Where("name = ?", req.Name).First(&existingCategory); result.Error == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "该分类名已存在"})
-		return
-	}
-
-	// 创建新分类
-	category := models.Category{
-		Name:        req.Name,
-		Description: req.Description,
-	}
-
-	if err := config.DB.Create(&category).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建分类失败"})
-		return
-	}
-
-	c.JSON(http.StatusCreated, gin.H{
-		"success": true,
-		"message": "分类创建成功",
-		"data":    category,
-	})
-}
-
-// 更新分类（管理员）
-func UpdateCategory(c *gin.Context) {
-	categoryID := c.Param("id")
-	if categoryID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "分类ID不能为空"})
-		return
-	}
-
-	// 验证请求
-	var req struct {
-		Name        string `json:"name" binding:"required"`
-		Description string `json:"description"`
-	}
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "验证失败: " + err.Error()})
-		return
-	}
-
-	// 检查分类是否存在
-	var category models.Category
-	if err := config.DB.First(&category, categoryID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "分类不存在"})
-		return
-	}
-
-	// 检查是否有其他分类使用相同名称
-	var existingCategory models.Category
-	if result := config.DB.Where("name = ? AND id != ?", req.Name, category.ID).First(&existingCategory); result.Error == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "该分类名已被其他分类使用"})
-		return
-	}
-
-	// 更新分类
-	category.Name = req.Name
-	category.Description = req.Description
-
-	if err := config.DB.Save(&category).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新分类失败"})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"message": "分类更新成功",
-		"data":    category,
-	})
-}
-
-// 删除分类（管理员）
-func DeleteCategory(c *gin.Context) {
-	categoryID := c.Param("id")
-	if categoryID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "分类ID不能为空"})
-		return
-	}
-
-	// 检查分类是否存在
-	var category models.Category
-	if err := config.DB.First(&category, categoryID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "分类不存在"})
-		return
-	}
-
-	// 检查是否有文章关联该分类
-	var count int64
-	config.DB.Table("post_categories").Where("category_id = ?", category.ID).Count(&count)
-	if count > 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "该分类下有关联的文章，无法删除"})
-		return
-	}
-
-	// 删除分类
-	if err := config.DB.Delete(&category).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除分类失败"})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"message": "分类删除成功",
-	})
-}
+package controllers
+
+import (
+	"blog/config"
+	"blog/models"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 获取所有分类（附带已发布文章数量）
+func GetCategories(c *gin.Context) {
+	type CategoryCount struct {
+		models.Category
+		PostCount int `json:"postCount"`
+	}
+
+	var categories []CategoryCount
+
+	// 查询所有分类及其已发布文章数量
+	err := config.DB.Raw(`
+		SELECT c.*, COUNT(p.id) as post_count
+		FROM categories c
+		LEFT JOIN post_categories pc ON c.id = pc.category_id
+		LEFT JOIN posts p ON pc.post_id = p.id AND p.status = 'published' AND p.deleted_at IS NULL
+		WHERE c.deleted_at IS NULL
+		GROUP BY c.id
+		ORDER BY c.name ASC
+	`).Scan(&categories).Error
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取分类列表失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": categories,
+	})
+}
+
+// 获取单个分类及其文章
+func GetCategory(c *gin.Context) {
+	categoryID := c.Param("id")
+	if categoryID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "分类ID不能为空"})
+		return
+	}
+
+	var category models.Category
+	if err := config.DB.First(&category, categoryID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "分类不存在"})
+		return
+	}
+
+	// 获取分页参数
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 50 {
+		pageSize = 10
+	}
+	offset := (page - 1) * pageSize
+
+	// 查询分类下的文章
+	var posts []models.Post
+	var total int64
+
+	query := config.DB.Model(&models.Post{}).
+		Joins("JOIN post_categories ON posts.id = post_categories.post_id").
+		Where("post_categories.category_id = ?", category.ID).
+		Scopes(scopeListedPosts)
+
+	// 游标分页
+	cursor, err := parseCursorPage(c, 10, 50)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if cursor.Enabled {
+		response := gin.H{}
+		if cursor.WithTotal {
+			query.Count(&total)
+			response["total"] = total
+		}
+		cursor.apply(query.Preload("User").Preload("Tags").Preload("Categories"), "posts").Find(&posts)
+		posts, meta := cursorResult(posts, cursor, postCursorKey)
+		redactProtectedPosts(c, postPointers(posts))
+		expandPostShortcodes(c, postPointers(posts))
+		response["data"] = posts
+		c.JSON(http.StatusOK, gin.H{
+			"category": category,
+			"posts":    withPageMeta(response, meta),
+		})
+		return
+	}
+
+	// 获取总数
+	query.Count(&total)
+
+	// 获取文章列表
+	query.Preload("User").Preload("Tags").Preload("Categories").
+		Order("posts.created_at DESC").
+		Offset(offset).Limit(pageSize).
+		Find(&posts)
+	redactProtectedPosts(c, postPointers(posts))
+	expandPostShortcodes(c, postPointers(posts))
+
+	c.JSON(http.StatusOK, gin.H{
+		"category": category,
+		"posts": gin.H{
+			"data":  posts,
+			"total": total,
+			"page":  page,
+			"size":  pageSize,
+		},
+	})
+}
+
+// 添加分类（管理员）
+func AddCategory(c *gin.Context) {
+	// 验证请求
+	var req struct {
+		Name        string `json:"name" binding:"required"`
+		Description string `json:"description"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "验证失败: " + err.Error()})
+		return
+	}
+
+	// 检查分类名是否已存在
+	var existingCategory models.Category
+	if result := config.DB.Where("name = ?", req.Name).First(&existingCategory); result.Error == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "该分类名已存在"})
+		return
+	}
+
+	// 创建新分类
+	category := models.Category{
+		Name:        req.Name,
+		Description: req.Description,
+	}
+
+	if err := config.DB.Create(&category).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建分类失败"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "分类创建成功",
+		"data":    category,
+	})
+}
+
+// 更新分类（管理员）
+func UpdateCategory(c *gin.Context) {
+	categoryID := c.Param("id")
+	if categoryID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "分类ID不能为空"})
+		return
+	}
+
+	// 验证请求
+	var req struct {
+		Name        string `json:"name" binding:"required"`
+		Description string `json:"description"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "验证失败: " + err.Error()})
+		return
+	}
+
+	// 检查分类是否存在
+	var category models.Category
+	if err := config.DB.First(&category, categoryID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "分类不存在"})
+		return
+	}
+
+	// 检查是否有其他分类使用相同名称
+	var existingCategory models.Category
+	if result := config.DB.Where("name = ? AND id != ?", req.Name, category.ID).First(&existingCategory); result.Error == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "该分类名已被其他分类使用"})
+		return
+	}
+
+	// 更新分类
+	category.Name = req.Name
+	category.Description = req.Description
+
+	if err := config.DB.Save(&category).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新分类失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "分类更新成功",
+		"data":    category,
+	})
+}
+
+// 删除分类（管理员）
+func DeleteCategory(c *gin.Context) {
+	categoryID := c.Param("id")
+	if categoryID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "分类ID不能为空"})
+		return
+	}
+
+	// 检查分类是否存在
+	var category models.Category
+	if err := config.DB.First(&category, categoryID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "分类不存在"})
+		return
+	}
+
+	// 检查是否有文章关联该分类
+	var count int64
+	config.DB.Table("post_categories").Where("category_id = ?", category.ID).Count(&count)
+	if count > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "该分类下有关联的文章，无法删除"})
+		return
+	}
+
+	// 使用该分类作为默认分类的文章模板改为不设置分类
+	config.DB.Model(&models.PostTemplate{}).Where("category_id = ?", category.ID).Update("category_id", nil)
+
+	// 删除分类
+	if err := config.DB.Delete(&category).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除分类失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "分类删除成功",
+	})
+}
+
+// 根据ID列表查询分类，存在无效ID时返回错误
+func findCategoriesByIDs(ids []uint) ([]models.Category, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var categories []models.Category
+	if err := config.DB.Where("id IN ?", ids).Find(&categories).Error; err != nil {
+		return nil, errors.New("查询分类失败")
+	}
+
+	found := make(map[uint]bool, len(categories))
+	for _, category := range categories {
+		found[category.ID] = true
+	}
+	for _, id := range ids {
+		if !found[id] {
+			return nil, fmt.Errorf("分类不存在: %d", id)
+		}
+	}
+
+	return categories, nil
+}
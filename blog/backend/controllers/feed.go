@@ -0,0 +1,117 @@
+package controllers
+
+import (
+	"blog/config"
+	"blog/models"
+	"encoding/xml"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 订阅源中的文章数
+const feedSize = 20
+
+// RSS 2.0 订阅源
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	Language      string    `xml:"language"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	GUID        string   `xml:"guid"`
+	Description string   `xml:"description"`
+	Categories  []string `xml:"category"`
+	PubDate     string   `xml:"pubDate"`
+}
+
+// 获取某种语言的 RSS 订阅源：lang 参数指定语言，未指定时按 Accept-Language 选择
+func GetFeed(c *gin.Context) {
+	lang := preferredLanguage(c)
+	if c.Query("lang") != "" {
+		var err error
+		if lang, err = normalizePostLanguage(c.Query("lang")); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	var posts []models.Post
+	if err := config.DB.Model(&models.Post{}).
+		Scopes(scopeListedPosts).
+		Where("posts.language = ?", lang).
+		Preload("Tags").
+		Order("posts.created_at DESC").
+		Limit(feedSize).
+		Find(&posts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取订阅源失败"})
+		return
+	}
+	redactProtectedPosts(c, postPointers(posts))
+
+	site := siteURL(c)
+	channel := rssChannel{
+		Title:       "Blog",
+		Link:        site,
+		Description: "Blog (" + lang + ")",
+		Language:    lang,
+		Items:       make([]rssItem, 0, len(posts)),
+	}
+	if len(posts) > 0 {
+		channel.LastBuildDate = posts[0].CreatedAt.Format(time.RFC1123Z)
+	}
+	for _, post := range posts {
+		link := postURL(site, &post)
+		item := rssItem{
+			Title:       post.Title,
+			Link:        link,
+			GUID:        link,
+			Description: post.Summary,
+			PubDate:     post.CreatedAt.Format(time.RFC1123Z),
+		}
+		for _, tag := range post.Tags {
+			item.Categories = append(item.Categories, tag.Name)
+		}
+		channel.Items = append(channel.Items, item)
+	}
+
+	c.Header("Content-Language", lang)
+	c.XML(http.StatusOK, rssFeed{Version: "2.0", Channel: channel})
+}
+
+// 站点地址，可通过环境变量 BLOG_SITE_URL 配置，未配置时使用请求的地址
+func siteURL(c *gin.Context) string {
+	if site := os.Getenv("BLOG_SITE_URL"); site != "" {
+		return strings.TrimRight(site, "/")
+	}
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host
+}
+
+// 文章的访问地址：有 slug 时使用 slug 和语言，否则使用文章ID
+func postURL(site string, post *models.Post) string {
+	if post.Slug != "" {
+		return site + "/posts/slug/" + url.PathEscape(post.Slug) + "?lang=" + post.Language
+	}
+	return site + "/posts/" + strconv.FormatUint(uint64(post.ID), 10)
+}
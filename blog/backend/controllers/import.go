@@ -0,0 +1,99 @@
+package controllers
+
+import (
+	"blog/config"
+	"blog/models"
+	"blog/services"
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 单次上传导入的最大大小
+const maxImportUploadSize = 50 << 20
+
+// 上传Markdown文件导入文章（管理员）
+// 表单字段files可以是多个.md文件或一个.zip压缩包；默认只返回预览报告，commit=true时才写入数据库
+func ImportMarkdown(c *gin.Context) {
+	user, _ := c.Get("user")
+	userModel := user.(models.User)
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportUploadSize)
+	form, err := c.MultipartForm()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "读取上传文件失败: " + err.Error()})
+		return
+	}
+
+	uploads := form.File["files"]
+	if len(uploads) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请上传Markdown文件或zip压缩包"})
+		return
+	}
+
+	var files []services.MarkdownFile
+	for _, upload := range uploads {
+		src, err := upload.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "读取文件失败: " + upload.Filename})
+			return
+		}
+		content, err := io.ReadAll(src)
+		src.Close()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "读取文件失败: " + upload.Filename})
+			return
+		}
+
+		switch strings.ToLower(filepath.Ext(upload.Filename)) {
+		case ".zip":
+			archived, err := services.ReadMarkdownZip(bytes.NewReader(content), int64(len(content)))
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "解析压缩包失败: " + err.Error()})
+				return
+			}
+			files = append(files, archived...)
+		case ".md", ".markdown":
+			files = append(files, services.MarkdownFile{Path: filepath.Base(upload.Filename), Content: content})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "不支持的文件类型: " + upload.Filename})
+			return
+		}
+	}
+
+	report, err := services.ImportMarkdown(files, userModel.ID, c.Query("commit") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "导入失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// 导出所有已发布文章为zip压缩包（管理员）
+// 压缩包中 posts 目录为带front matter的Markdown文件，可直接重新导入；html=true 时附带 site 目录下的静态站点
+func ExportBlog(c *gin.Context) {
+	opts := services.ExportOptions{
+		HTML:       c.Query("html") == "true",
+		UploadsDir: config.UploadsDir,
+		SiteTitle:  c.Query("title"),
+	}
+	if err := services.CheckExportOptions(opts); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", `attachment; filename="blog-export-`+time.Now().Format("20060102-150405")+`.zip"`)
+	if _, err := services.ExportToZip(c.Writer, opts); err != nil {
+		// 响应已开始写入，无法再返回JSON错误
+		log.Printf("导出失败: %v", err)
+		c.Abort()
+	}
+}
@@ -0,0 +1,222 @@
+package controllers
+
+import (
+	"blog/config"
+	"blog/models"
+	"blog/services"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 更新媒体信息请求
+type UpdateMediaRequest struct {
+	Alt string `json:"alt" binding:"max=500"`
+}
+
+// 上传图片到媒体库
+// 表单字段file为图片文件，alt为替代文本；按文件内容识别类型，去除EXIF并生成缩略图
+func UploadMedia(c *gin.Context) {
+	user, _ := c.Get("user")
+	userModel := user.(models.User)
+
+	// 多留1MB给表单其他字段
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, services.MaxMediaSize+1<<20)
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请上传图片文件（不超过10MB）"})
+		return
+	}
+	if file.Size > services.MaxMediaSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "图片大小不能超过10MB"})
+		return
+	}
+
+	data, err := readUploadedFile(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "读取上传文件失败"})
+		return
+	}
+
+	alt := strings.TrimSpace(c.PostForm("alt"))
+	if len([]rune(alt)) > 500 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "替代文本不能超过500个字符"})
+		return
+	}
+
+	media, err := services.SaveMedia(userModel.ID, file.Filename, alt, data)
+	if err != nil {
+		if isImageError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存图片失败"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, media)
+}
+
+// 获取媒体库列表，普通用户只能看到自己上传的，管理员可看到全部（可用userId过滤）
+// 支持keyword按文件名和替代文本搜索，type按MIME类型过滤（如image/png）
+func GetMediaList(c *gin.Context) {
+	user, _ := c.Get("user")
+	userModel := user.(models.User)
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+	offset := (page - 1) * pageSize
+
+	query := config.DB.Model(&models.Media{})
+	if userModel.Role != "admin" {
+		query = query.Where("user_id = ?", userModel.ID)
+	} else if userID := c.Query("userId"); userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	if keyword := strings.TrimSpace(c.Query("keyword")); keyword != "" {
+		search := fmt.Sprintf("%%%s%%", keyword)
+		query = query.Where("file_name ILIKE ? OR alt ILIKE ?", search, search)
+	}
+	if mimeType := c.Query("type"); mimeType != "" {
+		query = query.Where("mime_type = ?", mimeType)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var media []models.Media
+	query.Order("created_at DESC, id DESC").Offset(offset).Limit(pageSize).Find(&media)
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  media,
+		"total": total,
+		"page":  page,
+		"size":  pageSize,
+	})
+}
+
+// 获取单个媒体详情
+func GetMedia(c *gin.Context) {
+	media, ok := findOwnMedia(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, media)
+}
+
+// 更新媒体的替代文本
+func UpdateMedia(c *gin.Context) {
+	media, ok := findOwnMedia(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateMediaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := config.DB.Model(media).Update("alt", strings.TrimSpace(req.Alt)).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新媒体失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, media)
+}
+
+// 删除媒体及其文件
+// 仍被文章用作封面时返回409，force=true时同时清除这些文章的封面
+func DeleteMedia(c *gin.Context) {
+	media, ok := findOwnMedia(c)
+	if !ok {
+		return
+	}
+
+	var postIDs []uint
+	config.DB.Unscoped().Model(&models.Post{}).Where("cover_media_id = ?", media.ID).Pluck("id", &postIDs)
+	if len(postIDs) > 0 && c.Query("force") != "true" {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "该图片正被文章用作封面",
+			"postIds": postIDs,
+		})
+		return
+	}
+
+	if len(postIDs) > 0 {
+		if err := config.DB.Unscoped().Model(&models.Post{}).Where("id IN ?", postIDs).
+			UpdateColumns(map[string]interface{}{"cover": "", "cover_media_id": nil}).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "清除文章封面失败"})
+			return
+		}
+		invalidatePostCaches(postIDs)
+	}
+
+	if err := config.DB.Delete(media).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除媒体失败"})
+		return
+	}
+	services.DeleteMediaFiles(media)
+
+	c.JSON(http.StatusOK, gin.H{"message": "媒体已删除"})
+}
+
+// 查找媒体并检查权限（上传者或管理员），失败时写入错误响应
+func findOwnMedia(c *gin.Context) (*models.Media, bool) {
+	var media models.Media
+	if err := config.DB.First(&media, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "媒体不存在"})
+		return nil, false
+	}
+
+	user := currentUser(c)
+	if user == nil || (user.ID != media.UserID && user.Role != "admin") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权操作此媒体"})
+		return nil, false
+	}
+
+	return &media, true
+}
+
+// 读取上传文件的全部内容
+func readUploadedFile(file *multipart.FileHeader) ([]byte, error) {
+	src, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+	return io.ReadAll(src)
+}
+
+// 是否为图片校验失败（应返回400而不是500）
+func isImageError(err error) bool {
+	return errors.Is(err, services.ErrMediaTooLarge) ||
+		errors.Is(err, services.ErrUnsupportedMediaType) ||
+		errors.Is(err, services.ErrInvalidImage)
+}
+
+// 查找用作封面的媒体，只能使用自己上传的图片（管理员不受限制）
+func findCoverMedia(id uint, user *models.User) (*models.Media, error) {
+	var media models.Media
+	if err := config.DB.First(&media, id).Error; err != nil {
+		return nil, errors.New("封面图片不存在")
+	}
+	if media.UserID != user.ID && user.Role != "admin" {
+		return nil, errors.New("无权使用该封面图片")
+	}
+	return &media, nil
+}
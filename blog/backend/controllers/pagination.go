@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"blog/models"
+	"blog/utils"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 游标分页参数
+// 请求携带 cursor（向后翻页）、before（向前翻页）或 pagination=cursor（第一页）时启用，
+// 按 (created_at, id) 倒序进行键集分页，不再使用OFFSET；总数仅在 withTotal=true 时统计
+type cursorPage struct {
+	Enabled   bool
+	After     *utils.Cursor
+	Before    *utils.Cursor
+	Size      int
+	WithTotal bool
+}
+
+// 解析游标分页参数
+func parseCursorPage(c *gin.Context, defaultSize, maxSize int) (cursorPage, error) {
+	p := cursorPage{
+		Enabled:   c.Query("pagination") == "cursor" || c.Query("cursor") != "" || c.Query("before") != "",
+		WithTotal: c.Query("withTotal") == "true",
+	}
+
+	p.Size, _ = strconv.Atoi(c.DefaultQuery("pageSize", strconv.Itoa(defaultSize)))
+	if p.Size < 1 || p.Size > maxSize {
+		p.Size = defaultSize
+	}
+
+	var err error
+	if after := c.Query("cursor"); after != "" {
+		if p.After, err = utils.DecodeCursor(after); err != nil {
+			return p, err
+		}
+	} else if before := c.Query("before"); before != "" {
+		if p.Before, err = utils.DecodeCursor(before); err != nil {
+			return p, err
+		}
+	}
+
+	return p, nil
+}
+
+// 在查询上应用游标条件、排序和数量限制（多取一条用于判断是否还有更多数据）
+func (p cursorPage) apply(query *gorm.DB, table string) *gorm.DB {
+	createdAt := table + ".created_at"
+	id := table + ".id"
+
+	switch {
+	case p.After != nil:
+		query = query.Where("("+createdAt+", "+id+") < (?, ?)", p.After.CreatedAt, p.After.ID).
+			Order(createdAt + " DESC, " + id + " DESC")
+	case p.Before != nil:
+		// 向前翻页时先按正序取出，再在结果中反转
+		query = query.Where("("+createdAt+", "+id+") > (?, ?)", p.Before.CreatedAt, p.Before.ID).
+			Order(createdAt + " ASC, " + id + " ASC")
+	default:
+		query = query.Order(createdAt + " DESC, " + id + " DESC")
+	}
+
+	return query.Limit(p.Size + 1)
+}
+
+// 整理游标分页结果，返回本页数据及分页信息（nextCursor、prevCursor、hasMore）
+func cursorResult[T any](items []T, p cursorPage, key func(T) (time.Time, uint)) ([]T, gin.H) {
+	extra := len(items) > p.Size
+	if extra {
+		items = items[:p.Size]
+	}
+
+	if p.Before != nil {
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+		}
+	}
+
+	// 向后翻页时多出的一条说明还有更旧的数据；向前翻页时说明还有更新的数据
+	hasOlder := extra || p.Before != nil
+	hasNewer := p.After != nil || (p.Before != nil && extra)
+
+	meta := gin.H{
+		"nextCursor": nil,
+		"prevCursor": nil,
+		"hasMore":    false,
+		"size":       p.Size,
+	}
+	if len(items) > 0 {
+		if hasOlder {
+			meta["nextCursor"] = utils.EncodeCursor(key(items[len(items)-1]))
+			meta["hasMore"] = true
+		}
+		if hasNewer {
+			meta["prevCursor"] = utils.EncodeCursor(key(items[0]))
+		}
+	}
+
+	return items, meta
+}
+
+// 合并分页信息到响应
+func withPageMeta(data gin.H, meta gin.H) gin.H {
+	for k, v := range meta {
+		data[k] = v
+	}
+	return data
+}
+
+// 各列表的游标取值函数
+func postCursorKey(p models.Post) (time.Time, uint) { return p.CreatedAt, p.ID }
+
+func commentCursorKey(c models.Comment) (time.Time, uint) { return c.CreatedAt, c.ID }
+
+func notificationCursorKey(n models.Notification) (time.Time, uint) { return n.CreatedAt, n.ID }
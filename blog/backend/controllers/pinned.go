@@ -0,0 +1,184 @@
+package controllers
+
+import (
+	"blog/config"
+	"blog/models"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 置顶文章请求
+type PinPostRequest struct {
+	Order int        `json:"order"` // 置顶顺序，越小越靠前
+	Until *time.Time `json:"until"` // 置顶到期时间，省略表示一直置顶
+}
+
+// 当前有效的置顶条件（已置顶且未到期）
+const activePinnedCondition = "posts.pinned = ? AND (posts.pinned_until IS NULL OR posts.pinned_until > ?)"
+
+// 只保留当前有效的置顶文章，按置顶顺序排列
+func scopeActivePinned(query *gorm.DB) *gorm.DB {
+	return query.Where(activePinnedCondition, true, time.Now()).
+		Order("posts.pin_order ASC, posts.created_at DESC")
+}
+
+// 排除当前有效的置顶文章
+func scopeNotPinned(query *gorm.DB) *gorm.DB {
+	return query.Where("NOT ("+activePinnedCondition+")", true, time.Now())
+}
+
+// 置顶文章（管理员），已置顶的文章会更新顺序和到期时间
+func PinPost(c *gin.Context) {
+	// 请求体可以为空，表示以默认顺序一直置顶
+	var req PinPostRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "验证失败: " + err.Error()})
+		return
+	}
+	if req.Until != nil && !req.Until.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "置顶到期时间必须晚于当前时间"})
+		return
+	}
+
+	post, ok := findPublishedPost(c)
+	if !ok {
+		return
+	}
+
+	if err := config.DB.Model(&post).Updates(map[string]interface{}{
+		"pinned":       true,
+		"pin_order":    req.Order,
+		"pinned_until": req.Until,
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "置顶文章失败"})
+		return
+	}
+	post.Pinned, post.PinOrder, post.PinnedUntil = true, req.Order, req.Until
+	invalidatePostCaches([]uint{post.ID})
+
+	c.JSON(http.StatusOK, gin.H{"message": "文章已置顶", "data": post})
+}
+
+// 取消置顶（管理员）
+func UnpinPost(c *gin.Context) {
+	result := config.DB.Model(&models.Post{}).Where("id = ?", c.Param("id")).Updates(map[string]interface{}{
+		"pinned":       false,
+		"pin_order":    0,
+		"pinned_until": nil,
+	})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "取消置顶失败"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "文章不存在"})
+		return
+	}
+	if id, err := strconv.ParseUint(c.Param("id"), 10, 64); err == nil {
+		invalidatePostCaches([]uint{uint(id)})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "已取消置顶"})
+}
+
+// 设为编辑推荐（管理员）
+func FeaturePost(c *gin.Context) {
+	post, ok := findPublishedPost(c)
+	if !ok {
+		return
+	}
+
+	if !post.Featured {
+		now := time.Now()
+		if err := config.DB.Model(&post).Updates(map[string]interface{}{
+			"featured":    true,
+			"featured_at": now,
+		}).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "设置推荐失败"})
+			return
+		}
+		post.Featured, post.FeaturedAt = true, &now
+		invalidatePostCaches([]uint{post.ID})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "已设为推荐", "data": post})
+}
+
+// 取消编辑推荐（管理员）
+func UnfeaturePost(c *gin.Context) {
+	result := config.DB.Model(&models.Post{}).Where("id = ?", c.Param("id")).Updates(map[string]interface{}{
+		"featured":    false,
+		"featured_at": nil,
+	})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "取消推荐失败"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "文章不存在"})
+		return
+	}
+	if id, err := strconv.ParseUint(c.Param("id"), 10, 64); err == nil {
+		invalidatePostCaches([]uint{uint(id)})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "已取消推荐"})
+}
+
+// 获取全部置顶文章（管理员），包括已到期的，便于调整顺序
+func GetPinnedPosts(c *gin.Context) {
+	var posts []models.Post
+	if err := config.DB.Preload("User").
+		Where("pinned = ?", true).
+		Order("pin_order ASC, created_at DESC").
+		Find(&posts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取置顶文章失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": posts})
+}
+
+// 获取编辑推荐的文章，用于首页轮播，按设为推荐的时间倒序
+func GetFeaturedPosts(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "5"))
+	if limit < 1 || limit > 20 {
+		limit = 5
+	}
+
+	var posts []models.Post
+	if err := config.DB.Preload("User").Preload("Tags").Preload("Categories").Preload("CoverMedia").
+		Scopes(scopeListedPosts).
+		Where("posts.featured = ?", true).
+		Order("posts.featured_at DESC, posts.id DESC").
+		Limit(limit).
+		Find(&posts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取推荐文章失败"})
+		return
+	}
+	attachReactions(c, postPointers(posts))
+	redactProtectedPosts(c, postPointers(posts))
+	expandPostShortcodes(c, postPointers(posts))
+
+	c.JSON(http.StatusOK, gin.H{"data": posts})
+}
+
+// 查找已发布的文章，只有已发布的文章可以置顶或推荐
+func findPublishedPost(c *gin.Context) (models.Post, bool) {
+	var post models.Post
+	if err := config.DB.First(&post, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "文章不存在"})
+		return post, false
+	}
+	if post.Status != "published" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "只有已发布的文章可以置顶或推荐"})
+		return post, false
+	}
+	return post, true
+}
@@ -1,395 +1,797 @@
-package controllers
-
-import (
-	"blog/config"
-	"blog/models"
-	"blog/utils"
-	"context"
-	"fmt"
-	"net/http"
-	"strconv"
-	"sync"
-	"time"
-
-	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
-)
-
-// 创建文章请求
-type CreatePostRequest struct {
-	Title   string   `json:"title" binding:"required"`
-	Content string   `json:"content" binding:"required"`
-	Summary string   `json:"summary"`
-	Cover   string   `json:"cover"`
-	Status  string   `json:"status" binding:"required,oneof=draft published"`
-	Tags    []string `json:"tags"`
-}
-
-// 更新文章请求
-type UpdatePostRequest struct {
-	Title   string   `json:"title"`
-	Content string   `json:"content"`
-	Summary string   `json:"summary"`
-	Cover   string   `json:"cover"`
-	Status  string   `json:"status" binding:"omitempty,oneof=draft published"`
-	Tags    []string `json:"tags"`
-}
-
-// 获取所有文章
-func GetPosts(c *gin.Context) {
-	// 查询参数
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
-	status := c.DefaultQuery("status", "published")
-	tag := c.Query("tag")
-
-	offset := (page - 1) * pageSize
-	var posts []models.Post
-	var total int64
-	query := config.DB.Model(&models.Post{})
-
-	// 只获取已发布文章或者当前用户的草稿
-	if status == "all" {
-		// 如果用户是管理员，允许查看所有文章
-		user, exists := c.Get("user")
-		if exists {
-			userModel, ok := user.(models.User)
-			if ok && userModel.Role == "admin" {
-				// 管理员可以查看所有状态
-			} else {
-				// 非管理员只能查看自己的草稿和所有已发布文章
-				query = query.Where("status = 'published' OR (status = 'draft' AND user_id = ?)", userModel.ID)
-			}
-		} else {
-			// 未登录用户只能查看已发布文章
-			query = query.Where("status = 'published'")
-		}
-	} else {
-		query = query.Where("status = ?", status)
-	}
-
-	// 按标签过滤
-	if tag != "" {
-		query = query.Joins("JOIN post_tags ON posts.id = post_tags.post_id").
-			Joins("JOIN tags ON post_tags.tag_id = tags.id").
-			Where("tags.name = ?", tag)
-	}
-
-	// 使用WaitGroup等待两个并发操作完成：1.获取总数 2.获取分页数据
-	var wg sync.WaitGroup
-	wg.Add(2)
-
-	var queryErr error
-
-	// 并发获取总数
-	go func() {
-		defer wg.Done()
-		queryClone := query
-		if err := queryClone.Count(&total).Error; err != nil {
-			queryErr = err
-		}
-	}()
-
-	// 并发获取分页数据
-	go func() {
-		defer wg.Done()
-		queryClone := query
-		if err := queryClone.Preload("User").Preload("Tags").
-			Order("created_at DESC").
-			Offset(offset).
-			Limit(pageSize).
-			Find(&posts).Error; err != nil {
-			queryErr = err
-		}
-	}()
-
-	// 等待所有goroutine完成
-	wg.Wait()
-
-	// 处理可能出现的错误
-	if queryErr != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取文章失败: " + queryErr.Error()})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"data":  posts,
-		"total": total,
-		"page":  page,
-		"size":  pageSize,
-	})
-}
-
-// 获取单篇文章
-func GetPost(c *gin.Context) {
-	id := c.Param("id")
-	ctx := context.Background()
-
-	// 定义Redis缓存键
-	postCacheKey := fmt.Sprintf("post:%s", id)
-	viewCacheKey := fmt.Sprintf("post_view:%s", id)
-
-	// 尝试从Redis缓存获取文章
-	var post models.Post
-	postData, err := config.Redis.HGetAll(ctx, postCacheKey).Result()
-
-	// 如果缓存存在且不为空
-	if err == nil && len(postData) > 0 {
-		// 从缓存提取基本字段
-		post.ID = uint(utils.StringToUint(postData["id"]))
-		post.Title = postData["title"]
-		post.Content = postData["content"]
-		post.Summary = postData["summary"]
-		post.Cover = postData["cover"]
-		post.Status = postData["status"]
-		post.UserID = utils.StringToUint(postData["user_id"])
-		post.ViewCount = utils.StringToUint(postData["view_count"])
-		post.CreatedAt, _ = time.Parse(time.RFC3339, postData["created_at"])
-		post.UpdatedAt, _ = time.Parse(time.RFC3339, postData["updated_at"])
-
-		// 使用goroutine异步增加阅读计数，不阻塞主流程
-		go func() {
-			config.Redis.Incr(ctx, viewCacheKey)
-
-			// 检查是否需要同步到数据库
-			count, err := config.Redis.Get(ctx, viewCacheKey).Int()
-			if err == nil && count%10 == 0 {
-				// 每10次同步到数据库
-				config.DB.Model(&models.Post{}).Where("id = ?", id).Update("view_count", gorm.Expr("view_count + ?", 10))
-				// 重置计数器
-				config.Redis.Set(ctx, viewCacheKey, 0, time.Hour*24)
-				// 更新缓存中的阅读量
-				config.Redis.HIncrBy(ctx, postCacheKey, "view_count", 10)
-			}
-		}()
-	} else {
-		// 缓存不存在，从数据库获取
-		result := config.DB.Preload("User").Preload("Tags").Preload("Comments").Preload("Comments.User").First(&post, id)
-		if result.Error != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "文章不存在"})
-			return
-		}
-
-		// 使用goroutine异步设置缓存，不阻塞主流程
-		go func(p models.Post) {
-			// 将文章存入Redis缓存
-			cacheData := map[string]interface{}{
-				"id":         fmt.Sprintf("%d", p.ID),
-				"title":      p.Title,
-				"content":    p.Content,
-				"summary":    p.Summary,
-				"cover":      p.Cover,
-				"status":     p.Status,
-				"user_id":    fmt.Sprintf("%d", p.UserID),
-				"view_count": fmt.Sprintf("%d", p.ViewCount),
-				"created_at": p.CreatedAt.Format(time.RFC3339),
-				"updated_at": p.UpdatedAt.Format(time.RFC3339),
-			}
-
-			// 设置缓存，过期时间24小时
-			config.Redis.HMSet(ctx, postCacheKey, cacheData)
-			config.Redis.Expire(ctx, postCacheKey, time.Hour*24)
-
-			// 设置阅读计数器
-			config.Redis.Set(ctx, viewCacheKey, 0, time.Hour*24)
-			config.Redis.Incr(ctx, viewCacheKey)
-		}(post)
-	}
-
-	c.JSON(http.StatusOK, post)
-}
-
-// 创建文章
-func CreatePost(c *gin.Context) {
-	var req CreatePostRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "验证失败: " + err.Error()})
-		return
-	}
-
-	// 获取当前用户
-	user, _ := c.Get("user")
-	userModel := user.(models.User)
-
-	// 创建文章
-	post := models.Post{
-		Title:   req.Title,
-		Content: req.Content,
-		Summary: req.Summary,
-		Cover:   req.Cover,
-		Status:  req.Status,
-		UserID:  userModel.ID,
-	}
-
-	// 开始事务
-	tx := config.DB.Begin()
-
-	// 保存文章
-	if err := tx.Create(&post).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建文章失败: " + err.Error()})
-		return
-	}
-
-	// 处理标签
-	if len(req.Tags) > 0 {
-		for _, tagName := range req.Tags {
-			var tag models.Tag
-			// 查找或创建标签
-			if tx.Where("name = ?", tagName).First(&tag).Error != nil {
-				tag = models.Tag{Name: tagName}
-				if err := tx.Create(&tag).Error; err != nil {
-					tx.Rollback()
-					c.JSON(http.StatusInternalServerError, gin.H{"error": "创建标签失败: " + err.Error()})
-					return
-				}
-			}
-			// 添加标签到文章
-			if err := tx.Model(&post).Association("Tags").Append(&tag); err != nil {
-				tx.Rollback()
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "添加标签失败: " + err.Error()})
-				return
-			}
-		}
-	}
-
-	// 提交事务
-	tx.Commit()
-
-	c.JSON(http.StatusCreated, post)
-}
-
-// 更新文章
-func UpdatePost(c *gin.Context) {
-	id := c.Param("id")
-	var req UpdatePostRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "验证失败: " + err.Error()})
-		return
-	}
-
-	// 查找文章
-	var post models.Post
-	if result := config.DB.First(&post, id); result.Error != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "文章不存在"})
-		return
-	}
-
-	// 检查权限
-	user, _ := c.Get("user")
-	userModel := user.(models.User)
-	if post.UserID != userModel.ID && userModel.Role != "admin" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "无权修改此文章"})
-		return
-	}
-
-	// 开始事务
-	tx := config.DB.Begin()
-
-	// 更新文章
-	updates := map[string]interface{}{}
-	if req.Title != "" {
-		updates["title"] = req.Title
-	}
-	if req.Content != "" {
-		updates["content"] = req.Content
-	}
-	if req.Summary != "" {
-		updates["summary"] = req.Summary
-	}
-	if req.Cover != "" {
-		updates["cover"] = req.Cover
-	}
-	if req.Status != "" {
-		updates["status"] = req.Status
-	}
-
-	if err := tx.Model(&post).Updates(updates).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新文章失败: " + err.Error()})
-		return
-	}
-
-	// 处理标签
-	if len(req.Tags) > 0 {
-		// 清除旧标签
-		if err := tx.Model(&post).Association("Tags").Clear(); err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "清除标签失败: " + err.Error()})
-			return
-		}
-
-		// 添加新标签
-		for _, tagName := range req.Tags {
-			var tag models.Tag
-			// 查找或创建标签
-			if tx.Where("name = ?", tagName).First(&tag).Error != nil {
-				tag = models.Tag{Name: tagName}
-				if err := tx.Create(&tag).Error; err != nil {
-					tx.Rollback()
-					c.JSON(http.StatusInternalServerError, gin.H{"error": "创建标签失败: " + err.Error()})
-					return
-				}
-			}
-			// 添加标签到文章
-			if err := tx.Model(&post).Association("Tags").Append(&tag); err != nil {
-				tx.Rollback()
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "添加标签失败: " + err.Error()})
-				return
-			}
-		}
-	}
-
-	// 提交事务
-	tx.Commit()
-
-	// 重新加载文章
-	tx.Preload("User").Preload("Tags").First(&post, id)
-
-	// 使用goroutine异步执行缓存删除，不阻塞主流程
-	ctx := context.Background()
-	postCacheKey := fmt.Sprintf("post:%s", id)
-	go func() {
-		// 更新完成后，删除缓存，强制下次请求重新加载
-		config.Redis.Del(ctx, postCacheKey)
-	}()
-
-	c.JSON(http.StatusOK, post)
-}
-
-// 删除文章
-func DeletePost(c *gin.Context) {
-	id := c.Param("id")
-
-	// 查找文章
-	var post models.Post
-	if result := config.DB.First(&post, id); result.Error != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "文章不存在"})
-		return
-	}
-
-	// 检查权限
-	user, _ := c.Get("user")
-	userModel := user.(models.User)
-	if post.UserID != userModel.ID && userModel.Role != "admin" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "无权删除此文章"})
-		return
-	}
-
-	// 删除文章（软删除）
-	if result := config.DB.Delete(&post); result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除文章失败: " + result.Error.Error()})
-		return
-	}
-
-	// 使用goroutine异步执行缓存删除，不阻塞主流程
-	ctx := context.Background()
-	postCacheKey := fmt.Sprintf("post:%s", id)
-	viewCacheKey := fmt.Sprintf("post_view:%s", id)
-	go func() {
-		// 删除缓存
-		config.Redis.Del(ctx, postCacheKey, viewCacheKey)
-	}()
-
-	c.JSON(http.StatusOK, gin.H{"message": "文章已删除"})
-}
+package controllers
+
+import (
+	"blog/config"
+	"blog/models"
+	"blog/services"
+	"blog/utils"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 创建文章请求
+type CreatePostRequest struct {
+	Title         string   `json:"title"`   // 使用模板时可以省略，由模板生成
+	Content       string   `json:"content"` // 使用模板时可以省略，使用模板正文
+	Summary       string   `json:"summary"`
+	Cover         string   `json:"cover"`
+	CoverMediaID  *uint    `json:"coverMediaId"` // 使用媒体库中的图片作为封面，优先于cover
+	Status        string   `json:"status" binding:"required,oneof=draft published"`
+	Visibility    string   `json:"visibility" binding:"omitempty,oneof=public unlisted private password"`
+	Password      string   `json:"password"` // 可见性为password时必填
+	Tags          []string `json:"tags"`
+	CategoryIDs   []uint   `json:"categoryIds"`
+	Slug          string   `json:"slug"`          // 同一语言内唯一
+	Language      string   `json:"language"`      // 为空时使用默认语言
+	TranslationOf *uint    `json:"translationOf"` // 作为该文章的译文加入其翻译组
+	TemplateID    *uint    `json:"templateId"`    // 使用模板填充未指定的字段
+}
+
+// 更新文章请求
+type UpdatePostRequest struct {
+	Title         string   `json:"title"`
+	Content       string   `json:"content"`
+	Summary       string   `json:"summary"`
+	Cover         string   `json:"cover"`
+	CoverMediaID  *uint    `json:"coverMediaId"` // 为nil时不修改，传0时清除媒体封面
+	Status        string   `json:"status" binding:"omitempty,oneof=draft published"`
+	Visibility    string   `json:"visibility" binding:"omitempty,oneof=public unlisted private password"`
+	Password      string   `json:"password"` // 设置或修改访问密码
+	Tags          []string `json:"tags"`
+	CategoryIDs   []uint   `json:"categoryIds"` // 为nil时不修改，传空数组时清空分类
+	Slug          *string  `json:"slug"`        // 为nil时不修改，传空字符串时清除
+	Language      string   `json:"language"`
+	TranslationOf *uint    `json:"translationOf"` // 为nil时不修改，传0时移出翻译组
+}
+
+// 获取所有文章
+func GetPosts(c *gin.Context) {
+	// 查询参数
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
+	status := c.DefaultQuery("status", "published")
+
+	offset := (page - 1) * pageSize
+	var posts []models.Post
+	var total int64
+	query := config.DB.Model(&models.Post{})
+
+	// 只获取当前用户可见的文章：已发布且公开列出的文章，以及自己的全部文章
+	query = scopeVisiblePosts(c, query, status)
+
+	// 按标签、分类、作者、发布时间和封面过滤
+	filter, err := parsePostFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	query = filter.apply(query)
+
+	// 游标分页
+	cursor, err := parseCursorPage(c, 10, 50)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if cursor.Enabled && !filter.defaultOrder() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errCursorSort.Error()})
+		return
+	}
+
+	// 默认排序时有效的置顶文章单独放在第一页的 pinned 中，不再出现在普通列表里
+	var pinned []models.Post
+	withPinned := filter.defaultOrder() && page <= 1 && cursor.After == nil && cursor.Before == nil
+	if filter.defaultOrder() {
+		if withPinned {
+			pinned = []models.Post{}
+			if err := query.Session(&gorm.Session{}).Preload("User").Preload("Tags").Preload("Categories").
+				Scopes(scopeActivePinned).
+				Find(&pinned).Error; err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "获取置顶文章失败: " + err.Error()})
+				return
+			}
+			attachAuthors(postPointers(pinned))
+			attachReactions(c, postPointers(pinned))
+			redactProtectedPosts(c, postPointers(pinned))
+			expandPostShortcodes(c, postPointers(pinned))
+		}
+		query = query.Session(&gorm.Session{}).Scopes(scopeNotPinned)
+	}
+
+	if cursor.Enabled {
+		response := gin.H{}
+		if withPinned {
+			response["pinned"] = pinned
+		}
+		if cursor.WithTotal {
+			query.Count(&total)
+			response["total"] = total
+		}
+		if err := cursor.apply(query.Preload("User").Preload("Tags").Preload("Categories"), "posts").
+			Find(&posts).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "获取文章失败: " + err.Error()})
+			return
+		}
+		posts, meta := cursorResult(posts, cursor, postCursorKey)
+		attachAuthors(postPointers(posts))
+		attachReactions(c, postPointers(posts))
+		redactProtectedPosts(c, postPointers(posts))
+		expandPostShortcodes(c, postPointers(posts))
+		response["data"] = posts
+		c.JSON(http.StatusOK, withPageMeta(response, meta))
+		return
+	}
+
+	// 使用WaitGroup等待两个并发操作完成：1.获取总数 2.获取分页数据
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	var queryErr error
+
+	// 并发获取总数
+	go func() {
+		defer wg.Done()
+		queryClone := query
+		if err := queryClone.Count(&total).Error; err != nil {
+			queryErr = err
+		}
+	}()
+
+	// 并发获取分页数据
+	go func() {
+		defer wg.Done()
+		queryClone := query
+		if err := filter.order(queryClone.Preload("User").Preload("Tags").Preload("Categories")).
+			Offset(offset).
+			Limit(pageSize).
+			Find(&posts).Error; err != nil {
+			queryErr = err
+		}
+	}()
+
+	// 等待所有goroutine完成
+	wg.Wait()
+
+	// 处理可能出现的错误
+	if queryErr != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取文章失败: " + queryErr.Error()})
+		return
+	}
+
+	// 附加作者列表、表态计数及当前用户的表态，隐藏密码保护文章的内容
+	attachAuthors(postPointers(posts))
+	attachReactions(c, postPointers(posts))
+	redactProtectedPosts(c, postPointers(posts))
+	expandPostShortcodes(c, postPointers(posts))
+
+	response := gin.H{
+		"data":  posts,
+		"total": total,
+		"page":  page,
+		"size":  pageSize,
+	}
+	if withPinned {
+		response["pinned"] = pinned
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// 获取单篇文章
+func GetPost(c *gin.Context) {
+	id := c.Param("id")
+	ctx := context.Background()
+
+	// 定义Redis缓存键
+	postCacheKey := fmt.Sprintf("post:%s", id)
+
+	// 尝试从Redis缓存获取文章
+	var post models.Post
+	postData, err := config.Redis.HGetAll(ctx, postCacheKey).Result()
+
+	// 如果缓存存在且不为空（缺少阅读时间等字段的旧缓存视为未命中，reading_time 与其他字段一起写入）
+	if _, ok := postData["reading_time"]; err == nil && ok {
+		// 从缓存提取基本字段
+		post.ID = uint(utils.StringToUint(postData["id"]))
+		post.Title = postData["title"]
+		post.Slug = postData["slug"]
+		post.Language = postData["language"]
+		if translationOf := utils.StringToUint(postData["translation_of"]); translationOf != 0 {
+			post.TranslationOf = &translationOf
+		}
+		post.Content = postData["content"]
+		post.Summary = postData["summary"]
+		post.WordCount = int(utils.StringToUint(postData["word_count"]))
+		post.CharCount = int(utils.StringToUint(postData["char_count"]))
+		post.ReadingTime = int(utils.StringToUint(postData["reading_time"]))
+		post.TOC.Scan(postData["toc"])
+		post.Cover = postData["cover"]
+		if mediaID := utils.StringToUint(postData["cover_media_id"]); mediaID != 0 {
+			post.CoverMediaID = &mediaID
+		}
+		post.Status = postData["status"]
+		post.Visibility = postData["visibility"]
+		post.UserID = utils.StringToUint(postData["user_id"])
+		post.ViewCount = utils.StringToUint(postData["view_count"])
+		post.Pinned = postData["pinned"] == "1"
+		post.PinOrder, _ = strconv.Atoi(postData["pin_order"])
+		if until, err := time.Parse(time.RFC3339, postData["pinned_until"]); err == nil {
+			post.PinnedUntil = &until
+		}
+		post.Featured = postData["featured"] == "1"
+		if featuredAt, err := time.Parse(time.RFC3339, postData["featured_at"]); err == nil {
+			post.FeaturedAt = &featuredAt
+		}
+		post.CreatedAt, _ = time.Parse(time.RFC3339, postData["created_at"])
+		post.UpdatedAt, _ = time.Parse(time.RFC3339, postData["updated_at"])
+
+		// 缓存命中同样需要检查状态和可见性
+		if !checkPostAccess(c, &post) {
+			return
+		}
+
+	} else {
+		// 缓存不存在，从数据库获取
+		result := config.DB.Preload("User").Preload("Tags").Preload("Categories").Preload("Comments").Preload("Comments.User").First(&post, id)
+		if result.Error != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "文章不存在"})
+			return
+		}
+
+		if !checkPostAccess(c, &post) {
+			return
+		}
+
+		// 使用goroutine异步设置缓存，不阻塞主流程
+		go func(p models.Post) {
+			// 将文章存入Redis缓存
+			cacheData := map[string]interface{}{
+				"id":             fmt.Sprintf("%d", p.ID),
+				"title":          p.Title,
+				"slug":           p.Slug,
+				"language":       p.Language,
+				"translation_of": "",
+				"content":        p.Content,
+				"summary":        p.Summary,
+				"word_count":     fmt.Sprintf("%d", p.WordCount),
+				"char_count":     fmt.Sprintf("%d", p.CharCount),
+				"reading_time":   fmt.Sprintf("%d", p.ReadingTime),
+				"cover":          p.Cover,
+				"cover_media_id": "",
+				"status":         p.Status,
+				"visibility":     p.Visibility,
+				"user_id":        fmt.Sprintf("%d", p.UserID),
+				"view_count":     fmt.Sprintf("%d", p.ViewCount),
+				"pinned":         p.Pinned,
+				"pin_order":      fmt.Sprintf("%d", p.PinOrder),
+				"pinned_until":   "",
+				"featured":       p.Featured,
+				"featured_at":    "",
+				"created_at":     p.CreatedAt.Format(time.RFC3339),
+				"updated_at":     p.UpdatedAt.Format(time.RFC3339),
+			}
+			if p.TranslationOf != nil {
+				cacheData["translation_of"] = fmt.Sprintf("%d", *p.TranslationOf)
+			}
+			if p.CoverMediaID != nil {
+				cacheData["cover_media_id"] = fmt.Sprintf("%d", *p.CoverMediaID)
+			}
+			if p.PinnedUntil != nil {
+				cacheData["pinned_until"] = p.PinnedUntil.Format(time.RFC3339)
+			}
+			if p.FeaturedAt != nil {
+				cacheData["featured_at"] = p.FeaturedAt.Format(time.RFC3339)
+			}
+			if toc, err := p.TOC.Value(); err == nil {
+				cacheData["toc"] = toc
+			}
+
+			// 设置缓存，过期时间24小时
+			config.Redis.HMSet(ctx, postCacheKey, cacheData)
+			config.Redis.Expire(ctx, postCacheKey, time.Hour*24)
+		}(post)
+	}
+
+	// 记录浏览（作者本人除外），并加上尚未写入数据库的浏览量
+	if user := currentUser(c); user == nil || user.ID != post.UserID {
+		var userID uint
+		if user != nil {
+			userID = user.ID
+		}
+		visitorID := services.VisitorID(userID, c.ClientIP(), c.GetHeader("User-Agent"))
+		go services.RecordPostView(post.ID, visitorID)
+	}
+	post.ViewCount += services.PendingViews(post.ID)
+
+	// 展开正文中的片段短代码，编辑时传 raw=true 获取原始内容
+	if c.Query("raw") != "true" {
+		post.Content = services.ExpandShortcodes(post.Content)
+	}
+
+	// 附加系列导航信息（上一篇、下一篇及目录）
+	post.Series = loadSeriesNavigation(post.ID)
+
+	// 附加封面图片的各尺寸信息
+	if post.CoverMediaID != nil {
+		var media models.Media
+		if config.DB.First(&media, *post.CoverMediaID).Error == nil {
+			post.CoverMedia = &media
+		}
+	}
+
+	// 附加其他语言版本、作者列表、表态计数及当前用户的表态
+	attachTranslations(c, &post)
+	attachAuthors([]*models.Post{&post})
+	attachReactions(c, []*models.Post{&post})
+
+	c.JSON(http.StatusOK, post)
+}
+
+// 创建文章
+func CreatePost(c *gin.Context) {
+	var req CreatePostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "验证失败: " + err.Error()})
+		return
+	}
+
+	// 获取当前用户
+	user, _ := c.Get("user")
+	userModel := user.(models.User)
+
+	// 使用模板填充未指定的字段
+	var template *models.PostTemplate
+	if req.TemplateID != nil {
+		found, ok := findPostTemplate(c, *req.TemplateID)
+		if !ok {
+			return
+		}
+		template = &found
+		applyPostTemplate(&req, template, &userModel)
+	}
+	if req.Title == "" || req.Content == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "验证失败: 标题和内容不能为空"})
+		return
+	}
+
+	// 校验分类是否存在
+	categories, err := findCategoriesByIDs(req.CategoryIDs)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 创建文章
+	post := models.Post{
+		Title:      req.Title,
+		Content:    req.Content,
+		Summary:    req.Summary,
+		Cover:      req.Cover,
+		Status:     req.Status,
+		Visibility: req.Visibility,
+		UserID:     userModel.ID,
+	}
+	if post.Visibility == "" {
+		post.Visibility = models.VisibilityPublic
+	}
+	post.ComputeContentStats()
+
+	// 语言、slug 和翻译组
+	if post.Language, err = normalizePostLanguage(req.Language); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	post.Slug = strings.TrimSpace(req.Slug)
+	if err := checkSlugAvailable(post.Slug, post.Language, 0); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if req.TranslationOf != nil && *req.TranslationOf != 0 {
+		root, err := resolveTranslationGroup(c, *req.TranslationOf, post.Language, 0)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		post.TranslationOf = &root
+	}
+
+	// 开启审核后普通作者只能保存草稿，再提交审核
+	if post.Status == models.PostStatusPublished && !services.CanPublishDirectly(&userModel) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "发布文章需要提交审核，由编辑审核通过后发布"})
+		return
+	}
+
+	// 使用媒体库图片作为封面
+	if req.CoverMediaID != nil && *req.CoverMediaID != 0 {
+		media, err := findCoverMedia(*req.CoverMediaID, &userModel)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		post.CoverMediaID = &media.ID
+		post.Cover = media.VariantURL(models.MediaVariantLarge)
+	}
+
+	// 密码保护的文章必须设置密码
+	if post.Visibility == models.VisibilityPassword {
+		if req.Password == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "密码保护的文章必须设置访问密码"})
+			return
+		}
+		if err := post.SetAccessPassword(req.Password); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "设置访问密码失败"})
+			return
+		}
+	}
+
+	// 开始事务
+	tx := config.DB.Begin()
+
+	// 保存文章
+	if err := tx.Create(&post).Error; err != nil {
+		tx.Rollback()
+		if isSlugConflict(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": errSlugConflict.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建文章失败: " + err.Error()})
+		return
+	}
+
+	// 处理标签
+	if len(req.Tags) > 0 {
+		tags, err := services.FindOrCreateTags(tx, req.Tags)
+		if err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "创建标签失败: " + err.Error()})
+			return
+		}
+		// 添加标签到文章
+		if err := tx.Model(&post).Association("Tags").Append(tags); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "添加标签失败: " + err.Error()})
+			return
+		}
+	}
+
+	// 关联分类
+	if len(categories) > 0 {
+		if err := tx.Model(&post).Association("Categories").Append(categories); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "添加分类失败: " + err.Error()})
+			return
+		}
+	}
+
+	// 记录模板使用次数，用于生成下一篇的序号
+	if template != nil {
+		if err := tx.Model(template).UpdateColumn("use_count", gorm.Expr("use_count + 1")).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "更新模板失败: " + err.Error()})
+			return
+		}
+	}
+
+	// 提交事务
+	tx.Commit()
+
+	c.JSON(http.StatusCreated, post)
+}
+
+// 更新文章
+func UpdatePost(c *gin.Context) {
+	id := c.Param("id")
+	var req UpdatePostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "验证失败: " + err.Error()})
+		return
+	}
+
+	// 查找文章
+	var post models.Post
+	if result := config.DB.First(&post, id); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "文章不存在"})
+		return
+	}
+
+	// 检查权限：所有者、共同作者和管理员可以编辑
+	user, _ := c.Get("user")
+	userModel := user.(models.User)
+	if !canEditPost(c, &post) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权修改此文章"})
+		return
+	}
+	if err := checkStatusChange(c, &post, req.Status); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 校验分类是否存在
+	categories, err := findCategoriesByIDs(req.CategoryIDs)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 语言、slug 和翻译组，修改任一项都要重新检查唯一性
+	language, slug := post.Language, post.Slug
+	if req.Language != "" {
+		if language, err = normalizePostLanguage(req.Language); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if req.Slug != nil {
+		slug = strings.TrimSpace(*req.Slug)
+	}
+	if err := checkSlugAvailable(slug, language, post.ID); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	translationOf := post.TranslationOf
+	if req.TranslationOf != nil {
+		translationOf = nil
+		if *req.TranslationOf != 0 {
+			// 原文本身有译文时不能再加入其他翻译组
+			var translations int64
+			config.DB.Model(&models.Post{}).Where("translation_of = ?", post.ID).Count(&translations)
+			if translations > 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "该文章已有译文，不能再作为其他文章的译文"})
+				return
+			}
+			root, err := resolveTranslationGroup(c, *req.TranslationOf, language, post.ID)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			translationOf = &root
+		}
+	} else if language != post.Language {
+		// 只修改语言时检查翻译组中是否已有该语言
+		if err := checkTranslationLanguage(post.TranslationGroup(), language, post.ID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	// 开始事务
+	tx := config.DB.Begin()
+
+	// 更新文章
+	updates := map[string]interface{}{}
+	if req.Language != "" {
+		updates["language"] = language
+	}
+	if req.Slug != nil {
+		updates["slug"] = slug
+	}
+	if req.TranslationOf != nil {
+		updates["translation_of"] = translationOf
+	}
+	if req.Title != "" {
+		updates["title"] = req.Title
+	}
+	if req.Content != "" {
+		updates["content"] = req.Content
+		for column, value := range models.ContentStatsColumns(req.Content) {
+			updates[column] = value
+		}
+	}
+	if req.Summary != "" {
+		updates["summary"] = req.Summary
+	}
+	if req.Cover != "" {
+		// 直接设置封面链接时取消对媒体的引用
+		updates["cover"] = req.Cover
+		updates["cover_media_id"] = nil
+	}
+	if req.CoverMediaID != nil {
+		if *req.CoverMediaID == 0 {
+			if post.CoverMediaID != nil && req.Cover == "" {
+				updates["cover"] = ""
+			}
+			updates["cover_media_id"] = nil
+		} else {
+			media, err := findCoverMedia(*req.CoverMediaID, &userModel)
+			if err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			updates["cover"] = media.VariantURL(models.MediaVariantLarge)
+			updates["cover_media_id"] = media.ID
+		}
+	}
+	if req.Status != "" {
+		updates["status"] = req.Status
+	}
+
+	// 处理可见性及访问密码
+	visibility := post.Visibility
+	if req.Visibility != "" {
+		visibility = req.Visibility
+		updates["visibility"] = req.Visibility
+	}
+	if visibility == models.VisibilityPassword {
+		if req.Password != "" {
+			if err := post.SetAccessPassword(req.Password); err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "设置访问密码失败"})
+				return
+			}
+			updates["password"] = post.Password
+		} else if post.Password == "" {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": "密码保护的文章必须设置访问密码"})
+			return
+		}
+	} else if post.Password != "" {
+		updates["password"] = ""
+	}
+
+	if err := tx.Model(&post).Updates(updates).Error; err != nil {
+		tx.Rollback()
+		if isSlugConflict(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": errSlugConflict.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新文章失败: " + err.Error()})
+		return
+	}
+
+	// 处理标签
+	if len(req.Tags) > 0 {
+		// 清除旧标签
+		if err := tx.Model(&post).Association("Tags").Clear(); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "清除标签失败: " + err.Error()})
+			return
+		}
+
+		// 添加新标签
+		tags, err := services.FindOrCreateTags(tx, req.Tags)
+		if err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "创建标签失败: " + err.Error()})
+			return
+		}
+		if err := tx.Model(&post).Association("Tags").Append(tags); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "添加标签失败: " + err.Error()})
+			return
+		}
+	}
+
+	// 处理分类，请求中包含categoryIds时整体替换
+	if req.CategoryIDs != nil {
+		association := tx.Model(&post).Association("Categories")
+		var err error
+		if len(categories) == 0 {
+			err = association.Clear()
+		} else {
+			err = association.Replace(categories)
+		}
+		if err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "更新分类失败: " + err.Error()})
+			return
+		}
+	}
+
+	// 提交事务
+	tx.Commit()
+
+	// 重新加载文章
+	config.DB.Preload("User").Preload("Tags").Preload("Categories").First(&post, id)
+
+	// 使用goroutine异步执行缓存删除，不阻塞主流程
+	ctx := context.Background()
+	postCacheKey := fmt.Sprintf("post:%s", id)
+	go func() {
+		// 更新完成后，删除缓存，强制下次请求重新加载
+		config.Redis.Del(ctx, postCacheKey)
+	}()
+
+	c.JSON(http.StatusOK, post)
+}
+
+// 删除文章
+func DeletePost(c *gin.Context) {
+	id := c.Param("id")
+
+	// 查找文章
+	var post models.Post
+	if result := config.DB.First(&post, id); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "文章不存在"})
+		return
+	}
+
+	// 检查权限
+	user, _ := c.Get("user")
+	userModel := user.(models.User)
+	if post.UserID != userModel.ID && userModel.Role != "admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权删除此文章"})
+		return
+	}
+
+	// 删除文章（软删除）
+	if result := config.DB.Delete(&post); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除文章失败: " + result.Error.Error()})
+		return
+	}
+
+	// 使用goroutine异步执行缓存删除，不阻塞主流程
+	ctx := context.Background()
+	postCacheKey := fmt.Sprintf("post:%s", id)
+	go func() {
+		// 删除缓存
+		config.Redis.Del(ctx, postCacheKey)
+	}()
+
+	c.JSON(http.StatusOK, gin.H{"message": "文章已删除"})
+}
+
+// 获取相关文章
+func GetRelatedPosts(c *gin.Context) {
+	id := c.Param("id")
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "5"))
+	if limit < 1 || limit > services.RelatedPostsLimit {
+		limit = 5
+	}
+
+	var post models.Post
+	if err := config.DB.Select("id").First(&post, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "文章不存在"})
+		return
+	}
+
+	related := make([]models.Post, 0, limit)
+	excluded := []uint{post.ID}
+
+	// 优先使用后台预计算的相关文章
+	if ids := services.GetRelatedPostIDs(post.ID); len(ids) > 0 {
+		var candidates []models.Post
+		config.DB.Preload("User").Preload("Tags").Preload("Categories").
+			Scopes(scopeListedPosts).
+			Where("id IN ?", ids).
+			Find(&candidates)
+
+		// 按相似度顺序排列
+		byID := make(map[uint]models.Post, len(candidates))
+		for _, candidate := range candidates {
+			byID[candidate.ID] = candidate
+		}
+		for _, relatedID := range ids {
+			if candidate, ok := byID[relatedID]; ok && len(related) < limit {
+				related = append(related, candidate)
+				excluded = append(excluded, relatedID)
+			}
+		}
+	}
+
+	// 数量不足时使用热门文章补齐
+	if len(related) < limit {
+		var popular []models.Post
+		config.DB.Preload("User").Preload("Tags").Preload("Categories").
+			Scopes(scopeListedPosts).
+			Where("id NOT IN ?", excluded).
+			Order("view_count DESC, created_at DESC").
+			Limit(limit - len(related)).
+			Find(&popular)
+		related = append(related, popular...)
+	}
+
+	redactProtectedPosts(c, postPointers(related))
+	expandPostShortcodes(c, postPointers(related))
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": related,
+	})
+}
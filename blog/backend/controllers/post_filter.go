@@ -0,0 +1,235 @@
+package controllers
+
+import (
+	"blog/config"
+	"blog/models"
+	"blog/utils"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 文章列表的过滤和排序参数，GetPosts、GetUserPosts 和 GetTag 共用同一套查询参数：
+//
+//	tag       标签名，多个用逗号分隔或重复传参
+//	tagMode   any（默认，包含任一标签）或 all（包含全部标签）
+//	category  分类ID，多个用逗号分隔或重复传参，包含任一分类即可
+//	author    作者（所有者或共同作者）的用户ID或用户名
+//	year、month 或 from、to（YYYY-MM-DD，包含当天）  按发布时间过滤
+//	hasCover  true 只看有封面的文章，false 只看没有封面的文章
+//	lang      语言代码，多个用逗号分隔；auto 表示按 Accept-Language 选择最合适的语言
+//	sort      created_at（默认）、updated_at、views、comments、favorites
+//	order     desc（默认）或 asc
+type postFilter struct {
+	Tags       []string
+	MatchAll   bool
+	Categories []uint
+	AuthorID   uint
+	From, To   time.Time
+	HasCover   *bool
+	Languages  []string
+	Sort       string
+	Ascending  bool
+}
+
+// 支持的排序方式及对应的排序表达式
+var postSortColumns = map[string]string{
+	"created_at": "posts.created_at",
+	"updated_at": "posts.updated_at",
+	"views":      "posts.view_count",
+	"comments":   "(SELECT COUNT(*) FROM comments WHERE comments.post_id = posts.id AND comments.deleted_at IS NULL)",
+	"favorites":  "(SELECT COUNT(*) FROM favorites WHERE favorites.post_id = posts.id AND favorites.deleted_at IS NULL)",
+}
+
+// 解析文章列表的过滤和排序参数
+func parsePostFilter(c *gin.Context) (postFilter, error) {
+	var f postFilter
+	var err error
+
+	f.Tags = queryList(c, "tag")
+	switch c.DefaultQuery("tagMode", "any") {
+	case "any":
+	case "all":
+		f.MatchAll = true
+	default:
+		return f, errors.New("tagMode 只能为 any 或 all")
+	}
+
+	for _, value := range queryList(c, "category") {
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil || id == 0 {
+			return f, errors.New("无效的分类ID")
+		}
+		f.Categories = append(f.Categories, uint(id))
+	}
+
+	if author := strings.TrimSpace(c.Query("author")); author != "" {
+		if id, err := strconv.ParseUint(author, 10, 64); err == nil {
+			f.AuthorID = uint(id)
+		} else {
+			var user models.User
+			if err := config.DB.Select("id").Where("username = ?", author).First(&user).Error; err != nil {
+				return f, errors.New("作者不存在")
+			}
+			f.AuthorID = user.ID
+		}
+	}
+
+	if f.From, f.To, err = parseDateRange(c); err != nil {
+		return f, err
+	}
+
+	if value := c.Query("hasCover"); value != "" {
+		hasCover, err := strconv.ParseBool(value)
+		if err != nil {
+			return f, errors.New("hasCover 只能为 true 或 false")
+		}
+		f.HasCover = &hasCover
+	}
+
+	for _, lang := range queryList(c, "lang") {
+		if lang == "auto" {
+			lang = preferredLanguage(c)
+		}
+		lang = utils.NormalizeLanguage(lang)
+		if !models.IsSupportedLanguage(lang) {
+			return f, errors.New("不支持的语言: " + lang)
+		}
+		f.Languages = append(f.Languages, lang)
+	}
+
+	f.Sort = c.DefaultQuery("sort", "created_at")
+	if _, ok := postSortColumns[f.Sort]; !ok {
+		return f, errors.New("不支持的排序方式，可选 created_at、updated_at、views、comments、favorites")
+	}
+	switch c.DefaultQuery("order", "desc") {
+	case "desc":
+	case "asc":
+		f.Ascending = true
+	default:
+		return f, errors.New("order 只能为 asc 或 desc")
+	}
+
+	return f, nil
+}
+
+// 游标分页按 (created_at, id) 倒序翻页，只能与默认排序一起使用
+var errCursorSort = errors.New("游标分页仅支持按创建时间倒序排列")
+
+// 是否为默认的按创建时间倒序
+func (f postFilter) defaultOrder() bool {
+	return f.Sort == "created_at" && !f.Ascending
+}
+
+// 在查询上应用过滤条件
+func (f postFilter) apply(query *gorm.DB) *gorm.DB {
+	if len(f.Tags) > 0 {
+		tagged := config.DB.Table("post_tags").
+			Select("post_tags.post_id").
+			Joins("JOIN tags ON tags.id = post_tags.tag_id").
+			Where("tags.name IN ?", f.Tags)
+		if f.MatchAll {
+			tagged = tagged.Group("post_tags.post_id").
+				Having("COUNT(DISTINCT tags.id) = ?", len(f.Tags))
+		}
+		query = query.Where("posts.id IN (?)", tagged)
+	}
+
+	if len(f.Categories) > 0 {
+		query = query.Where("posts.id IN (?)", config.DB.Table("post_categories").
+			Select("post_id").
+			Where("category_id IN ?", f.Categories))
+	}
+
+	if f.AuthorID != 0 {
+		query = query.Where(config.DB.Where("posts.user_id = ?", f.AuthorID).
+			Or("posts.id IN (?)", acceptedAuthorPostIDs(f.AuthorID, models.AuthorRoleCoauthor)))
+	}
+
+	query = scopeDateRange(query, f.From, f.To)
+
+	if f.HasCover != nil {
+		if *f.HasCover {
+			query = query.Where("posts.cover IS NOT NULL AND posts.cover <> ''")
+		} else {
+			query = query.Where("posts.cover IS NULL OR posts.cover = ''")
+		}
+	}
+
+	if len(f.Languages) > 0 {
+		query = query.Where("posts.language IN ?", f.Languages)
+	}
+
+	return query
+}
+
+// 在查询上应用排序，排序值相同时按ID保证顺序稳定
+func (f postFilter) order(query *gorm.DB) *gorm.DB {
+	direction := " DESC"
+	if f.Ascending {
+		direction = " ASC"
+	}
+	return query.Order(postSortColumns[f.Sort] + direction + ", posts.id" + direction)
+}
+
+// 读取可重复且可用逗号分隔的查询参数，去除空白、空值和重复值
+func queryList(c *gin.Context, key string) []string {
+	var values []string
+	seen := map[string]bool{}
+	for _, raw := range c.QueryArray(key) {
+		for _, value := range strings.Split(raw, ",") {
+			if value = strings.TrimSpace(value); value != "" && !seen[value] {
+				seen[value] = true
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
+
+// 解析文章列表的时间过滤参数：year、month 或 from、to（YYYY-MM-DD，包含当天）
+// 未指定任何参数时返回零值，表示不过滤
+func parseDateRange(c *gin.Context) (time.Time, time.Time, error) {
+	if year := c.Query("year"); year != "" {
+		return archivePeriod(year, c.Query("month"))
+	}
+	if c.Query("month") != "" {
+		return time.Time{}, time.Time{}, errors.New("按月份过滤时必须指定年份")
+	}
+
+	_, loc := utils.LocalZone()
+	var from, to time.Time
+	if value := c.Query("from"); value != "" {
+		date, err := time.ParseInLocation("2006-01-02", value, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("无效的开始日期，格式应为YYYY-MM-DD")
+		}
+		from = date
+	}
+	if value := c.Query("to"); value != "" {
+		date, err := time.ParseInLocation("2006-01-02", value, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("无效的结束日期，格式应为YYYY-MM-DD")
+		}
+		to = date.AddDate(0, 0, 1)
+	}
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		return time.Time{}, time.Time{}, errors.New("开始日期不能晚于结束日期")
+	}
+	return from, to, nil
+}
+
+// 按创建时间过滤文章，零值表示该端不限
+func scopeDateRange(query *gorm.DB, from, to time.Time) *gorm.DB {
+	if !from.IsZero() {
+		query = query.Where("posts.created_at >= ?", from)
+	}
+	if !to.IsZero() {
+		query = query.Where("posts.created_at < ?", to)
+	}
+	return query
+}
@@ -0,0 +1,194 @@
+package controllers
+
+import (
+	"blog/config"
+	"blog/models"
+	"blog/services"
+	"blog/utils"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 预览链接的默认和最长有效期
+const (
+	defaultPreviewTTL = 72 * time.Hour
+	maxPreviewTTL     = 30 * 24 * time.Hour
+)
+
+// 创建预览链接请求
+type CreatePreviewLinkRequest struct {
+	ExpiresIn int    `json:"expiresIn" binding:"min=0"` // 有效期（小时），默认72，最长720
+	Note      string `json:"note" binding:"max=200"`
+}
+
+// 为未发布的文章创建预览链接（可编辑文章的作者和管理员），令牌只在创建时返回一次
+func CreatePreviewLink(c *gin.Context) {
+	var req CreatePreviewLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "验证失败: " + err.Error()})
+		return
+	}
+
+	post, ok := findEditablePost(c)
+	if !ok {
+		return
+	}
+	if post.Status == models.PostStatusPublished {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "文章已发布，无需预览链接"})
+		return
+	}
+
+	ttl := defaultPreviewTTL
+	if req.ExpiresIn > 0 {
+		ttl = time.Duration(req.ExpiresIn) * time.Hour
+	}
+	if ttl > maxPreviewTTL {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "预览链接最长有效30天"})
+		return
+	}
+
+	user := currentUser(c)
+	link := models.PostPreviewLink{
+		PostID:      post.ID,
+		CreatedByID: user.ID,
+		Note:        req.Note,
+		ExpiresAt:   time.Now().Add(ttl),
+	}
+	if err := config.DB.Create(&link).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建预览链接失败"})
+		return
+	}
+
+	token, err := utils.GeneratePostPreviewToken(post.ID, link.ID, link.ExpiresAt)
+	if err != nil {
+		config.DB.Delete(&link)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成预览令牌失败"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"link":  link,
+		"token": token,
+		"url":   siteURL(c) + "/api/v1/preview/" + token,
+	})
+}
+
+// 获取文章的预览链接列表（可编辑文章的作者和管理员），包括已过期和已撤销的
+func GetPreviewLinks(c *gin.Context) {
+	post, ok := findEditablePost(c)
+	if !ok {
+		return
+	}
+
+	var links []models.PostPreviewLink
+	config.DB.Preload("CreatedBy").
+		Where("post_id = ?", post.ID).
+		Order("created_at DESC").
+		Find(&links)
+
+	items := make([]gin.H, 0, len(links))
+	for i := range links {
+		items = append(items, gin.H{
+			"link":   links[i],
+			"active": links[i].Active(),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": items,
+	})
+}
+
+// 撤销单个预览链接
+func RevokePreviewLink(c *gin.Context) {
+	post, ok := findEditablePost(c)
+	if !ok {
+		return
+	}
+
+	var link models.PostPreviewLink
+	if err := config.DB.Where("id = ? AND post_id = ?", c.Param("previewId"), post.ID).First(&link).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "预览链接不存在"})
+		return
+	}
+	if link.RevokedAt == nil {
+		now := time.Now()
+		if err := config.DB.Model(&link).Update("revoked_at", now).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "撤销预览链接失败"})
+			return
+		}
+		link.RevokedAt = &now
+	}
+
+	c.JSON(http.StatusOK, link)
+}
+
+// 撤销文章所有未撤销的预览链接
+func RevokeAllPreviewLinks(c *gin.Context) {
+	post, ok := findEditablePost(c)
+	if !ok {
+		return
+	}
+
+	result := config.DB.Model(&models.PostPreviewLink{}).
+		Where("post_id = ? AND revoked_at IS NULL", post.ID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "撤销预览链接失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"revoked": result.RowsAffected,
+	})
+}
+
+// 通过预览令牌查看文章，无需登录，不受发布状态和可见性限制
+func GetPostPreview(c *gin.Context) {
+	claims, err := utils.ParsePostPreviewToken(c.Param("token"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "预览链接无效或已过期"})
+		return
+	}
+
+	var link models.PostPreviewLink
+	if err := config.DB.Where("id = ? AND post_id = ?", claims.LinkID, claims.PostID).First(&link).Error; err != nil || !link.Active() {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "预览链接无效或已过期"})
+		return
+	}
+
+	var post models.Post
+	if err := config.DB.Preload("User").Preload("Tags").Preload("Categories").First(&post, link.PostID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "文章不存在"})
+		return
+	}
+
+	// 记录预览次数，不计入文章浏览量
+	config.DB.Model(&link).Updates(map[string]interface{}{
+		"view_count":   gorm.Expr("view_count + 1"),
+		"last_used_at": time.Now(),
+	})
+
+	post.Content = services.ExpandShortcodes(post.Content)
+	if post.CoverMediaID != nil {
+		var media models.Media
+		if config.DB.First(&media, *post.CoverMediaID).Error == nil {
+			post.CoverMedia = &media
+		}
+	}
+	attachAuthors([]*models.Post{&post})
+
+	// 预览页面不应被搜索引擎收录
+	c.Header("X-Robots-Tag", "noindex, nofollow")
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{
+		"post":      post,
+		"preview":   true,
+		"expiresAt": link.ExpiresAt,
+	})
+}
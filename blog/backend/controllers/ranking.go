@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"blog/config"
+	"blog/models"
+	"blog/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 排行中的文章，附带排行分数
+type rankedPost struct {
+	models.Post
+	Score float64 `json:"score"`
+}
+
+// 获取趋势文章：按互动分数随发布时间衰减后排序，新近且互动多的文章靠前
+func GetTrendingPosts(c *gin.Context) {
+	getRankedPosts(c, services.RankingTrending)
+}
+
+// 获取热门文章：按统计窗口内的浏览、点赞、评论和收藏加权总分排序
+func GetPopularPosts(c *gin.Context) {
+	getRankedPosts(c, services.RankingPopular)
+}
+
+// window 可选 day、week（默认）、month、all
+func getRankedPosts(c *gin.Context, kind string) {
+	window := c.DefaultQuery("window", services.RankingWindowWeek)
+	if _, ok := services.RankingWindows[window]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的统计窗口，可选 day、week、month、all"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 50 {
+		pageSize = 10
+	}
+
+	ranking, err := services.GetRankedPosts(kind, window, (page-1)*pageSize, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取文章排行失败"})
+		return
+	}
+
+	ids := make([]uint, 0, len(ranking.Posts))
+	for _, item := range ranking.Posts {
+		ids = append(ids, item.PostID)
+	}
+	var posts []models.Post
+	if len(ids) > 0 {
+		config.DB.Preload("User").Preload("Tags").Preload("Categories").
+			Scopes(scopeListedPosts).
+			Where("id IN ?", ids).
+			Find(&posts)
+	}
+	attachReactions(c, postPointers(posts))
+	redactProtectedPosts(c, postPointers(posts))
+	expandPostShortcodes(c, postPointers(posts))
+
+	// 按排名顺序排列，排行计算后已删除或隐藏的文章跳过
+	byID := make(map[uint]models.Post, len(posts))
+	for _, post := range posts {
+		byID[post.ID] = post
+	}
+	data := make([]rankedPost, 0, len(ranking.Posts))
+	for _, item := range ranking.Posts {
+		if post, ok := byID[item.PostID]; ok {
+			data = append(data, rankedPost{Post: post, Score: item.Score})
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":       data,
+		"total":      ranking.Total,
+		"page":       page,
+		"size":       pageSize,
+		"window":     window,
+		"computedAt": ranking.ComputedAt, // 为null表示排行正在后台计算
+	})
+}
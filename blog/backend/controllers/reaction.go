@@ -0,0 +1,150 @@
+package controllers
+
+import (
+	"blog/config"
+	"blog/models"
+	"blog/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 表态请求
+type ReactionRequest struct {
+	Type   string `json:"type" binding:"required"`
+	Active *bool  `json:"active"` // 指定目标状态时重复请求结果一致；为空时切换当前状态
+}
+
+// 获取文章表态统计及当前用户的表态
+func GetPostReactions(c *gin.Context) {
+	var post models.Post
+	if err := config.DB.Select("id").First(&post, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "文章不存在"})
+		return
+	}
+
+	attachReactions(c, []*models.Post{&post})
+
+	c.JSON(http.StatusOK, gin.H{
+		"counts": post.Reactions,
+		"mine":   post.MyReactions,
+	})
+}
+
+// 设置或切换文章表态
+func ReactToPost(c *gin.Context) {
+	var req ReactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "验证失败: " + err.Error()})
+		return
+	}
+	setPostReaction(c, req.Type, req.Active)
+}
+
+// 点赞或取消点赞（切换）
+func ToggleLike(c *gin.Context) {
+	setPostReaction(c, models.ReactionTypeLike, nil)
+}
+
+// 取消表态
+func RemovePostReaction(c *gin.Context) {
+	active := false
+	setPostReaction(c, c.Param("type"), &active)
+}
+
+// 将当前用户对文章的表态设置为目标状态
+func setPostReaction(c *gin.Context, reactionType string, active *bool) {
+	if !models.IsValidReactionType(reactionType) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "不支持的表态类型"})
+		return
+	}
+
+	user, _ := c.Get("user")
+	userModel := user.(models.User)
+
+	var post models.Post
+	if err := config.DB.Select("id, title, status, visibility, user_id").First(&post, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "文章不存在"})
+		return
+	}
+	if !checkPostAccess(c, &post) {
+		return
+	}
+	if post.Status != "published" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "只能对已发布的文章表态"})
+		return
+	}
+
+	// 未指定目标状态时根据当前状态切换
+	target := true
+	if active != nil {
+		target = *active
+	} else {
+		var count int64
+		config.DB.Model(&models.Reaction{}).
+			Where("user_id = ? AND post_id = ? AND type = ?", userModel.ID, post.ID, reactionType).
+			Count(&count)
+		target = count == 0
+	}
+
+	var err error
+	if target {
+		_, err = services.AddReaction(userModel.ID, post.ID, reactionType)
+	} else {
+		_, err = services.RemoveReaction(userModel.ID, post.ID, reactionType)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新表态失败: " + err.Error()})
+		return
+	}
+
+	attachReactions(c, []*models.Post{&post})
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"type":    reactionType,
+		"reacted": target,
+		"counts":  post.Reactions,
+		"mine":    post.MyReactions,
+	})
+}
+
+// 为文章附加表态计数，已登录时附加当前用户的表态
+func attachReactions(c *gin.Context, posts []*models.Post) {
+	if len(posts) == 0 {
+		return
+	}
+
+	ids := make([]uint, 0, len(posts))
+	for _, post := range posts {
+		ids = append(ids, post.ID)
+	}
+
+	counts := services.GetReactionCounts(ids)
+
+	var mine map[uint][]string
+	if user, exists := c.Get("user"); exists {
+		if userModel, ok := user.(models.User); ok {
+			mine = services.GetUserReactions(userModel.ID, ids)
+		}
+	}
+
+	for _, post := range posts {
+		post.Reactions = counts[post.ID]
+		if mine != nil {
+			post.MyReactions = mine[post.ID]
+			if post.MyReactions == nil {
+				post.MyReactions = []string{}
+			}
+		}
+	}
+}
+
+// 取文章切片中每个元素的指针
+func postPointers(posts []models.Post) []*models.Post {
+	pointers := make([]*models.Post, len(posts))
+	for i := range posts {
+		pointers[i] = &posts[i]
+	}
+	return pointers
+}
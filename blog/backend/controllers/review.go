@@ -0,0 +1,456 @@
+package controllers
+
+import (
+	"blog/config"
+	"blog/models"
+	"blog/services"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 提交审核请求，reviewerId 可选
+type SubmitReviewRequest struct {
+	ReviewerID *uint  `json:"reviewerId"`
+	Note       string `json:"note"` // 给审核人的说明
+}
+
+// 审核文章请求
+type ReviewPostRequest struct {
+	Action  string `json:"action" binding:"required,oneof=approve request_changes"`
+	Comment string `json:"comment"` // 要求修改时必填
+}
+
+// 指定审核人请求
+type AssignReviewerRequest struct {
+	ReviewerID uint `json:"reviewerId" binding:"required"`
+}
+
+// 添加审核意见请求
+type CreateReviewCommentRequest struct {
+	Content string `json:"content" binding:"required"`
+	Line    int    `json:"line" binding:"min=0"`
+	Quote   string `json:"quote" binding:"max=500"`
+}
+
+// 当前用户是否为编辑或管理员（以数据库中的角色为准）
+func isEditor(c *gin.Context) bool {
+	user := currentUser(c)
+	return user != nil && services.IsEditor(user.ID)
+}
+
+// 是否处于审核流程中（已提交或被要求修改）
+func inReview(post *models.Post) bool {
+	return post.Status == models.PostStatusPendingReview || post.Status == models.PostStatusChangesRequested
+}
+
+// 当前用户能否查看文章的审核意见：文章作者、编辑和管理员
+func canViewReview(c *gin.Context, post *models.Post) bool {
+	return isEditor(c) || isPostAuthor(c, post)
+}
+
+// 检查当前用户能否把文章设为某个状态，不能时返回错误说明
+// 发布需要直接发布权限；其余状态变化必须符合审核流程
+func checkStatusChange(c *gin.Context, post *models.Post, status string) error {
+	if status == "" || status == post.Status {
+		return nil
+	}
+	user := currentUser(c)
+	switch status {
+	case models.PostStatusPublished:
+		if !services.CanPublishDirectly(user) {
+			return errors.New("发布文章需要提交审核，由编辑审核通过后发布")
+		}
+		return nil
+	case models.PostStatusDraft:
+		if post.Status == models.PostStatusPublished || models.CanTransition(post.Status, status) {
+			return nil
+		}
+	}
+	return fmt.Errorf("文章不能从 %s 变为 %s", post.Status, status)
+}
+
+// 查找文章并检查当前用户能否编辑，失败时写入错误响应
+func findEditablePost(c *gin.Context) (models.Post, bool) {
+	var post models.Post
+	if err := config.DB.First(&post, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "文章不存在"})
+		return post, false
+	}
+	if !canEditPost(c, &post) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权修改此文章"})
+		return post, false
+	}
+	return post, true
+}
+
+// 查找可以担任审核人的用户（编辑或管理员）
+func findReviewer(id uint) (models.User, error) {
+	var reviewer models.User
+	if err := config.DB.Select("id, username, role").First(&reviewer, id).Error; err != nil {
+		return reviewer, errors.New("审核人不存在")
+	}
+	if !reviewer.IsEditor() {
+		return reviewer, errors.New("审核人必须是编辑或管理员")
+	}
+	return reviewer, nil
+}
+
+// 更新文章状态并记录审核历史，在同一事务中执行
+func transitionPost(post *models.Post, actorID uint, action, status, note string, updates map[string]interface{}) error {
+	if updates == nil {
+		updates = map[string]interface{}{}
+	}
+	updates["status"] = status
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(post).Updates(updates).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.ReviewComment{
+			PostID:  post.ID,
+			UserID:  actorID,
+			Action:  action,
+			Content: note,
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+	post.Status = status
+	invalidatePostCaches([]uint{post.ID})
+	return nil
+}
+
+// 通知文章所有者和共同作者（操作者本人除外）
+func notifyPostAuthors(post *models.Post, actor *models.User, content string) {
+	var coauthors []uint
+	config.DB.Model(&models.PostAuthor{}).
+		Where("post_id = ? AND role = ? AND status = ?", post.ID, models.AuthorRoleCoauthor, models.AuthorStatusAccepted).
+		Pluck("user_id", &coauthors)
+	recipients := append([]uint{post.UserID}, coauthors...)
+
+	for _, userID := range recipients {
+		if userID != actor.ID {
+			CreateNotification(userID, models.NotificationTypeReview, content, &actor.ID, &post.ID, nil, fmt.Sprintf("/posts/%d/review", post.ID))
+		}
+	}
+}
+
+// 通知审核人；未指定审核人时通知所有编辑和管理员（操作者本人除外）
+func notifyReviewers(post *models.Post, actor *models.User, content string) {
+	recipients := services.EditorIDs()
+	if post.ReviewerID != nil {
+		recipients = []uint{*post.ReviewerID}
+	}
+	for _, userID := range recipients {
+		if userID != actor.ID {
+			CreateNotification(userID, models.NotificationTypeReview, content, &actor.ID, &post.ID, nil, fmt.Sprintf("/posts/%d/review", post.ID))
+		}
+	}
+}
+
+// 提交文章审核（作者），草稿和被要求修改的文章可以提交
+func SubmitPostForReview(c *gin.Context) {
+	var req SubmitReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "验证失败: " + err.Error()})
+		return
+	}
+
+	post, ok := findEditablePost(c)
+	if !ok {
+		return
+	}
+	if !models.CanTransition(post.Status, models.PostStatusPendingReview) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "只有草稿或被要求修改的文章可以提交审核"})
+		return
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{"submitted_at": now}
+	if req.ReviewerID != nil {
+		reviewer, err := findReviewer(*req.ReviewerID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		updates["reviewer_id"] = reviewer.ID
+		post.ReviewerID = &reviewer.ID
+	}
+
+	user := currentUser(c)
+	if err := transitionPost(&post, user.ID, models.ReviewActionSubmit, models.PostStatusPendingReview, req.Note, updates); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "提交审核失败"})
+		return
+	}
+	post.SubmittedAt = &now
+
+	notifyReviewers(&post, user, fmt.Sprintf("%s 提交了文章《%s》，等待审核", user.Username, post.Title))
+
+	c.JSON(http.StatusOK, gin.H{"message": "已提交审核", "data": post})
+}
+
+// 撤回审核（作者），文章回到草稿状态
+func WithdrawPostReview(c *gin.Context) {
+	post, ok := findEditablePost(c)
+	if !ok {
+		return
+	}
+	if post.Status != models.PostStatusPendingReview {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "文章不在审核中"})
+		return
+	}
+
+	user := currentUser(c)
+	if err := transitionPost(&post, user.ID, models.ReviewActionWithdraw, models.PostStatusDraft, "", nil); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "撤回审核失败"})
+		return
+	}
+
+	if post.ReviewerID != nil {
+		notifyReviewers(&post, user, fmt.Sprintf("%s 撤回了文章《%s》的审核", user.Username, post.Title))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "已撤回审核", "data": post})
+}
+
+// 审核文章（编辑和管理员）：通过后直接发布，或要求作者修改
+func ReviewPost(c *gin.Context) {
+	var req ReviewPostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "验证失败: " + err.Error()})
+		return
+	}
+	if req.Action == models.ReviewActionRequestChanges && req.Comment == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "要求修改时请填写审核意见"})
+		return
+	}
+	if !isEditor(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "只有编辑和管理员可以审核文章"})
+		return
+	}
+
+	var post models.Post
+	if err := config.DB.First(&post, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "文章不存在"})
+		return
+	}
+	if post.Status != models.PostStatusPendingReview {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "文章不在审核中"})
+		return
+	}
+
+	user := currentUser(c)
+	status, content := models.PostStatusPublished, fmt.Sprintf("%s 审核通过了你的文章《%s》，文章已发布", user.Username, post.Title)
+	if req.Action == models.ReviewActionRequestChanges {
+		status, content = models.PostStatusChangesRequested, fmt.Sprintf("%s 要求修改你的文章《%s》：%s", user.Username, post.Title, req.Comment)
+	}
+
+	// 未指定审核人时由实际审核的人担任
+	updates := map[string]interface{}{}
+	if post.ReviewerID == nil {
+		updates["reviewer_id"] = user.ID
+		post.ReviewerID = &user.ID
+	}
+	if err := transitionPost(&post, user.ID, req.Action, status, req.Comment, updates); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "审核文章失败"})
+		return
+	}
+
+	notifyPostAuthors(&post, user, content)
+
+	c.JSON(http.StatusOK, gin.H{"message": "审核完成", "data": post})
+}
+
+// 指定或更换审核人（作者、编辑和管理员），审核人会收到通知
+func AssignReviewer(c *gin.Context) {
+	var req AssignReviewerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "验证失败: " + err.Error()})
+		return
+	}
+
+	var post models.Post
+	if err := config.DB.First(&post, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "文章不存在"})
+		return
+	}
+	if !isEditor(c) && !canEditPost(c, &post) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权指定审核人"})
+		return
+	}
+
+	reviewer, err := findReviewer(req.ReviewerID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := config.DB.Model(&post).Update("reviewer_id", reviewer.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "指定审核人失败"})
+		return
+	}
+	post.ReviewerID = &reviewer.ID
+
+	if inReview(&post) {
+		user := currentUser(c)
+		notifyReviewers(&post, user, fmt.Sprintf("%s 请你审核文章《%s》", user.Username, post.Title))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "已指定审核人", "data": post})
+}
+
+// 获取待审核的文章（编辑和管理员），assigned=me 时只看指定给自己的，按提交时间先后排列
+func GetReviewQueue(c *gin.Context) {
+	if !isEditor(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "只有编辑和管理员可以查看待审核文章"})
+		return
+	}
+
+	query := config.DB.Model(&models.Post{}).Where("posts.status = ?", models.PostStatusPendingReview)
+	if c.Query("assigned") == "me" {
+		query = query.Where("posts.reviewer_id = ?", currentUser(c).ID)
+	}
+
+	var posts []models.Post
+	if err := query.Preload("User").Preload("Reviewer").
+		Order("posts.submitted_at ASC, posts.id ASC").
+		Find(&posts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取待审核文章失败"})
+		return
+	}
+	attachAuthors(postPointers(posts))
+
+	c.JSON(http.StatusOK, gin.H{"data": posts})
+}
+
+// 获取文章的审核意见和审核历史（作者、编辑和管理员）
+func GetReviewComments(c *gin.Context) {
+	var post models.Post
+	if err := config.DB.First(&post, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "文章不存在"})
+		return
+	}
+	if !canViewReview(c, &post) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权查看此文章的审核意见"})
+		return
+	}
+
+	var comments []models.ReviewComment
+	if err := config.DB.Preload("User").
+		Where("post_id = ?", post.ID).
+		Order("created_at ASC").
+		Find(&comments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取审核意见失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":   post.Status,
+		"reviewer": post.ReviewerID,
+		"data":     comments,
+	})
+}
+
+// 添加审核意见（作者、编辑和管理员），可以针对正文的某一行或引用的片段
+func CreateReviewComment(c *gin.Context) {
+	var req CreateReviewCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "验证失败: " + err.Error()})
+		return
+	}
+
+	var post models.Post
+	if err := config.DB.First(&post, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "文章不存在"})
+		return
+	}
+	if !canViewReview(c, &post) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权评审此文章"})
+		return
+	}
+
+	user := currentUser(c)
+	comment := models.ReviewComment{
+		PostID:  post.ID,
+		UserID:  user.ID,
+		Content: req.Content,
+		Line:    req.Line,
+		Quote:   req.Quote,
+	}
+	if err := config.DB.Create(&comment).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "添加审核意见失败"})
+		return
+	}
+	comment.User = *user
+
+	c.JSON(http.StatusCreated, comment)
+}
+
+// 标记审核意见为已解决或未解决（作者、编辑和管理员）
+func ResolveReviewComment(c *gin.Context) {
+	var req struct {
+		Resolved bool `json:"resolved"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "验证失败: " + err.Error()})
+		return
+	}
+
+	post, comment, ok := findReviewComment(c)
+	if !ok {
+		return
+	}
+	if !canViewReview(c, &post) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权修改此审核意见"})
+		return
+	}
+
+	if err := config.DB.Model(&comment).Update("resolved", req.Resolved).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "修改审核意见失败"})
+		return
+	}
+	comment.Resolved = req.Resolved
+
+	c.JSON(http.StatusOK, comment)
+}
+
+// 删除审核意见（发表者本人和管理员），审核历史记录不能删除
+func DeleteReviewComment(c *gin.Context) {
+	_, comment, ok := findReviewComment(c)
+	if !ok {
+		return
+	}
+	user := currentUser(c)
+	if comment.Action != "" || (comment.UserID != user.ID && user.Role != models.RoleAdmin) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权删除此审核意见"})
+		return
+	}
+
+	if err := config.DB.Delete(&comment).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除审核意见失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "审核意见已删除"})
+}
+
+// 按路由参数 id 和 commentId 查找文章及审核意见，找不到时写入错误响应
+func findReviewComment(c *gin.Context) (models.Post, models.ReviewComment, bool) {
+	var post models.Post
+	var comment models.ReviewComment
+	if err := config.DB.First(&post, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "文章不存在"})
+		return post, comment, false
+	}
+	if err := config.DB.Where("id = ? AND post_id = ?", c.Param("commentId"), post.ID).First(&comment).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "审核意见不存在"})
+		return post, comment, false
+	}
+	return post, comment, true
+}
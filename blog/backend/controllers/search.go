@@ -0,0 +1,249 @@
+package controllers
+
+import (
+	"blog/config"
+	"blog/models"
+	"blog/utils"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 文章搜索结果
+type PostSearchResult struct {
+	models.Post
+	TitleHighlight string `json:"titleHighlight"`
+	Snippet        string `json:"snippet"`
+}
+
+// 评论搜索结果
+type CommentSearchResult struct {
+	models.Comment
+	PostTitle string `json:"postTitle"`
+	Snippet   string `json:"snippet"`
+}
+
+// 搜索片段长度（字符数）
+const searchSnippetLength = 120
+
+// 全文搜索文章或评论
+func Search(c *gin.Context) {
+	keyword := strings.TrimSpace(c.Query("q"))
+	if keyword == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "搜索关键词不能为空"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 50 {
+		pageSize = 10
+	}
+	offset := (page - 1) * pageSize
+
+	tsQuery, args, terms := buildSearchQuery(keyword, false)
+	if tsQuery == "" {
+		c.JSON(http.StatusOK, gin.H{"data": []interface{}{}, "total": 0, "page": page, "size": pageSize})
+		return
+	}
+
+	switch c.DefaultQuery("type", "post") {
+	case "comment":
+		searchComments(c, tsQuery, args, terms, page, pageSize, offset)
+	default:
+		searchPosts(c, tsQuery, args, terms, page, pageSize, offset)
+	}
+}
+
+// 搜索文章
+func searchPosts(c *gin.Context, tsQuery string, args []interface{}, terms []string, page, pageSize, offset int) {
+	query := config.DB.Model(&models.Post{}).
+		Scopes(scopeListedPosts).
+		Where("posts.search_vector @@ "+tsQuery, args...)
+	query = applySearchFilters(c, query, "posts.user_id", "posts.created_at")
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "搜索失败: " + err.Error()})
+		return
+	}
+
+	var posts []models.Post
+	if err := query.Select("posts.*, ts_rank_cd(posts.search_vector, "+tsQuery+") AS search_rank", args...).
+		Preload("User").Preload("Tags").Preload("Categories").
+		Order("search_rank DESC, posts.created_at DESC").
+		Offset(offset).Limit(pageSize).
+		Find(&posts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "搜索失败: " + err.Error()})
+		return
+	}
+
+	// 密码保护文章只展示标题
+	redactProtectedPosts(c, postPointers(posts))
+	expandPostShortcodes(c, postPointers(posts))
+
+	results := make([]PostSearchResult, 0, len(posts))
+	for _, post := range posts {
+		// 摘要命中时优先展示摘要，否则从正文中截取
+		source := post.Content
+		if post.Summary != "" && containsAnyTerm(post.Summary, terms) {
+			source = post.Summary
+		}
+		results = append(results, PostSearchResult{
+			Post:           post,
+			TitleHighlight: utils.HighlightSnippet(post.Title, terms, len([]rune(post.Title))),
+			Snippet:        utils.HighlightSnippet(source, terms, searchSnippetLength),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  results,
+		"total": total,
+		"page":  page,
+		"size":  pageSize,
+	})
+}
+
+// 搜索评论（仅限已发布文章下的评论）
+func searchComments(c *gin.Context, tsQuery string, args []interface{}, terms []string, page, pageSize, offset int) {
+	query := config.DB.Model(&models.Comment{}).
+		Joins("JOIN posts ON posts.id = comments.post_id AND posts.deleted_at IS NULL").
+		Scopes(scopeListedPosts).
+		Where("posts.visibility <> ?", models.VisibilityPassword).
+		Where("comments.search_vector @@ "+tsQuery, args...)
+	query = applySearchFilters(c, query, "comments.user_id", "comments.created_at")
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "搜索失败: " + err.Error()})
+		return
+	}
+
+	var comments []models.Comment
+	if err := query.Select("comments.*, ts_rank_cd(comments.search_vector, "+tsQuery+") AS search_rank", args...).
+		Preload("User").Preload("Post").
+		Order("search_rank DESC, comments.created_at DESC").
+		Offset(offset).Limit(pageSize).
+		Find(&comments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "搜索失败: " + err.Error()})
+		return
+	}
+
+	results := make([]CommentSearchResult, 0, len(comments))
+	for _, comment := range comments {
+		results = append(results, CommentSearchResult{
+			Comment:   comment,
+			PostTitle: comment.Post.Title,
+			Snippet:   utils.HighlightSnippet(comment.Content, terms, searchSnippetLength),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  results,
+		"total": total,
+		"page":  page,
+		"size":  pageSize,
+	})
+}
+
+// 搜索联想（边输入边提示）
+func SearchSuggest(c *gin.Context) {
+	keyword := strings.TrimSpace(c.Query("q"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "8"))
+	if limit < 1 || limit > 20 {
+		limit = 8
+	}
+
+	type PostSuggestion struct {
+		ID    uint   `json:"id"`
+		Title string `json:"title"`
+	}
+
+	posts := []PostSuggestion{}
+	tags := []models.Tag{}
+
+	if keyword == "" {
+		c.JSON(http.StatusOK, gin.H{"posts": posts, "tags": tags})
+		return
+	}
+
+	// 最后一个词条按前缀匹配
+	tsQuery, args, _ := buildSearchQuery(keyword, true)
+	if tsQuery != "" {
+		config.DB.Model(&models.Post{}).
+			Select("posts.id, posts.title").
+			Scopes(scopeListedPosts).
+			Where("(posts.search_vector @@ "+tsQuery+" OR posts.title ILIKE ?)", append(args, keyword+"%")...).
+			Order("posts.view_count DESC, posts.created_at DESC").
+			Limit(limit).
+			Scan(&posts)
+	}
+
+	config.DB.Where("name ILIKE ?", keyword+"%").Order("name ASC").Limit(limit).Find(&tags)
+
+	c.JSON(http.StatusOK, gin.H{
+		"posts": posts,
+		"tags":  tags,
+	})
+}
+
+// 构建tsquery表达式，返回SQL片段、参数和用于高亮的词条
+func buildSearchQuery(keyword string, prefix bool) (string, []interface{}, []string) {
+	if config.SearchNgram {
+		terms := utils.SearchTerms(keyword)
+		if len(terms) == 0 {
+			return "", nil, nil
+		}
+		return "to_tsquery(?::regconfig, ?)", []interface{}{config.SearchConfig, utils.BuildTSQuery(terms, prefix)}, terms
+	}
+
+	// 分词模式下交给PostgreSQL解析，高亮时按空白切分
+	terms := strings.Fields(strings.ToLower(keyword))
+	if prefix {
+		words := utils.SearchTerms(keyword)
+		if len(words) == 0 {
+			return "", nil, nil
+		}
+		return "to_tsquery(?::regconfig, ?)", []interface{}{config.SearchConfig, utils.BuildTSQuery(words, true)}, terms
+	}
+	return "websearch_to_tsquery(?::regconfig, ?)", []interface{}{config.SearchConfig, keyword}, terms
+}
+
+// 应用搜索过滤条件：标签、分类、作者、日期范围
+func applySearchFilters(c *gin.Context, query *gorm.DB, userColumn, dateColumn string) *gorm.DB {
+	if tag := c.Query("tag"); tag != "" {
+		query = query.Where(`EXISTS (SELECT 1 FROM post_tags pt JOIN tags t ON t.id = pt.tag_id
+			WHERE pt.post_id = posts.id AND t.name = ?)`, tag)
+	}
+	if categoryID := c.Query("category"); categoryID != "" {
+		query = query.Where("EXISTS (SELECT 1 FROM post_categories pc WHERE pc.post_id = posts.id AND pc.category_id = ?)", categoryID)
+	}
+	if authorID := c.Query("author"); authorID != "" {
+		query = query.Where(userColumn+" = ?", authorID)
+	}
+	if from, err := time.ParseInLocation("2006-01-02", c.Query("from"), time.Local); err == nil {
+		query = query.Where(dateColumn+" >= ?", from)
+	}
+	if to, err := time.ParseInLocation("2006-01-02", c.Query("to"), time.Local); err == nil {
+		query = query.Where(dateColumn+" < ?", to.AddDate(0, 0, 1))
+	}
+	return query
+}
+
+// 检查文本是否包含任一词条
+func containsAnyTerm(text string, terms []string) bool {
+	lower := strings.ToLower(text)
+	for _, term := range terms {
+		if strings.Contains(lower, term) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,497 @@
+package controllers
+
+import (
+	"blog/config"
+	"blog/models"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 创建系列请求
+type CreateSeriesRequest struct {
+	Title       string `json:"title" binding:"required,max=200"`
+	Description string `json:"description" binding:"max=500"`
+}
+
+// 更新系列请求
+type UpdateSeriesRequest struct {
+	Title       string `json:"title" binding:"max=200"`
+	Description string `json:"description" binding:"max=500"`
+}
+
+// 向系列添加文章请求
+type AddSeriesPostRequest struct {
+	PostID   uint `json:"postId" binding:"required"`
+	Position int  `json:"position"` // 插入位置（从1开始），为0时追加到末尾
+}
+
+// 调整系列文章顺序请求
+type ReorderSeriesRequest struct {
+	PostIDs []uint `json:"postIds" binding:"required"`
+}
+
+// 获取系列列表
+func GetSeriesList(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 50 {
+		pageSize = 10
+	}
+	offset := (page - 1) * pageSize
+
+	query := config.DB.Model(&models.Series{})
+
+	// 按作者过滤
+	if userID := c.Query("userId"); userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	type SeriesWithCount struct {
+		models.Series
+		PostCount int `json:"postCount"`
+	}
+
+	var seriesList []models.Series
+	if err := query.Preload("User").
+		Order("created_at DESC").
+		Offset(offset).Limit(pageSize).
+		Find(&seriesList).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取系列列表失败"})
+		return
+	}
+
+	// 统计每个系列中已发布的文章数
+	seriesIDs := make([]uint, 0, len(seriesList))
+	for _, s := range seriesList {
+		seriesIDs = append(seriesIDs, s.ID)
+	}
+	counts := make(map[uint]int)
+	if len(seriesIDs) > 0 {
+		var rows []struct {
+			SeriesID  uint
+			PostCount int
+		}
+		config.DB.Model(&models.SeriesPost{}).
+			Select("series_posts.series_id, COUNT(*) AS post_count").
+			Joins("JOIN posts ON posts.id = series_posts.post_id AND posts.deleted_at IS NULL").
+			Where("series_posts.series_id IN ? AND posts.status = ? AND posts.visibility <> ?", seriesIDs, "published", models.VisibilityPrivate).
+			Group("series_posts.series_id").
+			Scan(&rows)
+		for _, row := range rows {
+			counts[row.SeriesID] = row.PostCount
+		}
+	}
+
+	result := make([]SeriesWithCount, 0, len(seriesList))
+	for _, s := range seriesList {
+		result = append(result, SeriesWithCount{Series: s, PostCount: counts[s.ID]})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  result,
+		"total": total,
+		"page":  page,
+		"size":  pageSize,
+	})
+}
+
+// 获取单个系列及其有序文章目录
+func GetSeries(c *gin.Context) {
+	seriesID := c.Param("id")
+
+	var series models.Series
+	if err := config.DB.Preload("User").First(&series, seriesID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "系列不存在"})
+		return
+	}
+
+	// 系列作者和管理员可以看到草稿，其他人只能看到已发布文章
+	query := config.DB.Model(&models.SeriesPost{}).
+		Joins("JOIN posts ON posts.id = series_posts.post_id AND posts.deleted_at IS NULL").
+		Where("series_posts.series_id = ?", series.ID)
+	if !canManageSeries(c, &series) {
+		query = query.Where("posts.status = ? AND posts.visibility <> ?", "published", models.VisibilityPrivate)
+	}
+
+	var items []models.SeriesPost
+	if err := query.Preload("Post").Preload("Post.User").Preload("Post.Tags").
+		Order("series_posts.position ASC").
+		Find(&items).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取系列文章失败"})
+		return
+	}
+
+	// 隐藏密码保护文章的内容
+	posts := make([]*models.Post, len(items))
+	for i := range items {
+		posts[i] = &items[i].Post
+	}
+	redactProtectedPosts(c, posts)
+	expandPostShortcodes(c, posts)
+
+	series.Items = items
+
+	c.JSON(http.StatusOK, series)
+}
+
+// 创建系列
+func CreateSeries(c *gin.Context) {
+	var req CreateSeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "验证失败: " + err.Error()})
+		return
+	}
+
+	user, _ := c.Get("user")
+	userModel := user.(models.User)
+
+	series := models.Series{
+		Title:       req.Title,
+		Description: req.Description,
+		UserID:      userModel.ID,
+	}
+
+	if err := config.DB.Create(&series).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建系列失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, series)
+}
+
+// 更新系列
+func UpdateSeries(c *gin.Context) {
+	seriesID := c.Param("id")
+	var req UpdateSeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "验证失败: " + err.Error()})
+		return
+	}
+
+	var series models.Series
+	if err := config.DB.First(&series, seriesID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "系列不存在"})
+		return
+	}
+
+	if !canManageSeries(c, &series) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权修改此系列"})
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if req.Title != "" {
+		updates["title"] = req.Title
+	}
+	if req.Description != "" {
+		updates["description"] = req.Description
+	}
+
+	if err := config.DB.Model(&series).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新系列失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, series)
+}
+
+// 删除系列（文章本身保留，只解除关联）
+func DeleteSeries(c *gin.Context) {
+	seriesID := c.Param("id")
+
+	var series models.Series
+	if err := config.DB.First(&series, seriesID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "系列不存在"})
+		return
+	}
+
+	if !canManageSeries(c, &series) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权删除此系列"})
+		return
+	}
+
+	tx := config.DB.Begin()
+
+	if err := tx.Where("series_id = ?", series.ID).Delete(&models.SeriesPost{}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "解除系列文章关联失败"})
+		return
+	}
+
+	if err := tx.Delete(&series).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除系列失败"})
+		return
+	}
+
+	tx.Commit()
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "系列已删除",
+	})
+}
+
+// 向系列添加文章
+func AddSeriesPost(c *gin.Context) {
+	seriesID := c.Param("id")
+	var req AddSeriesPostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "验证失败: " + err.Error()})
+		return
+	}
+
+	var series models.Series
+	if err := config.DB.First(&series, seriesID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "系列不存在"})
+		return
+	}
+
+	if !canManageSeries(c, &series) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权修改此系列"})
+		return
+	}
+
+	var post models.Post
+	if err := config.DB.First(&post, req.PostID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "文章不存在"})
+		return
+	}
+
+	// 只能把系列作者自己的文章加入系列（管理员除外）
+	user, _ := c.Get("user")
+	userModel := user.(models.User)
+	if post.UserID != series.UserID && userModel.Role != "admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "只能添加系列作者的文章"})
+		return
+	}
+
+	// 检查文章是否已属于某个系列
+	var existing models.SeriesPost
+	if result := config.DB.Where("post_id = ?", post.ID).First(&existing); result.Error == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "该文章已属于其他系列"})
+		return
+	}
+
+	tx := config.DB.Begin()
+
+	var count int64
+	tx.Model(&models.SeriesPost{}).Where("series_id = ?", series.ID).Count(&count)
+
+	position := req.Position
+	if position <= 0 || position > int(count) {
+		// 追加到末尾
+		position = int(count) + 1
+	} else {
+		// 插入到指定位置，后续文章依次后移
+		if err := tx.Model(&models.SeriesPost{}).
+			Where("series_id = ? AND position >= ?", series.ID, position).
+			Update("position", gorm.Expr("position + 1")).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "调整文章顺序失败"})
+			return
+		}
+	}
+
+	item := models.SeriesPost{
+		SeriesID: series.ID,
+		PostID:   post.ID,
+		Position: position,
+	}
+	if err := tx.Create(&item).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "添加文章到系列失败: " + err.Error()})
+		return
+	}
+
+	tx.Commit()
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "文章已加入系列",
+		"data":    item,
+	})
+}
+
+// 从系列移除文章
+func RemoveSeriesPost(c *gin.Context) {
+	seriesID := c.Param("id")
+	postID := c.Param("postId")
+
+	var series models.Series
+	if err := config.DB.First(&series, seriesID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "系列不存在"})
+		return
+	}
+
+	if !canManageSeries(c, &series) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权修改此系列"})
+		return
+	}
+
+	var item models.SeriesPost
+	if err := config.DB.Where("series_id = ? AND post_id = ?", series.ID, postID).First(&item).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "该文章不在此系列中"})
+		return
+	}
+
+	tx := config.DB.Begin()
+
+	if err := tx.Delete(&item).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "移除文章失败"})
+		return
+	}
+
+	// 后续文章依次前移，保持顺序连续
+	if err := tx.Model(&models.SeriesPost{}).
+		Where("series_id = ? AND position > ?", series.ID, item.Position).
+		Update("position", gorm.Expr("position - 1")).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "调整文章顺序失败"})
+		return
+	}
+
+	tx.Commit()
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "文章已从系列移除",
+	})
+}
+
+// 调整系列中文章的顺序
+func ReorderSeriesPosts(c *gin.Context) {
+	seriesID := c.Param("id")
+	var req ReorderSeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "验证失败: " + err.Error()})
+		return
+	}
+
+	var series models.Series
+	if err := config.DB.First(&series, seriesID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "系列不存在"})
+		return
+	}
+
+	if !canManageSeries(c, &series) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权修改此系列"})
+		return
+	}
+
+	// 新顺序必须恰好包含系列中的全部文章
+	currentIDs := seriesPostIDs(config.DB, series.ID)
+	current := make(map[uint]bool, len(currentIDs))
+	for _, id := range currentIDs {
+		current[id] = true
+	}
+	if len(req.PostIDs) != len(currentIDs) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "文章列表必须包含系列中的全部文章"})
+		return
+	}
+	seen := make(map[uint]bool, len(req.PostIDs))
+	for _, id := range req.PostIDs {
+		if !current[id] || seen[id] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "文章列表包含无效或重复的文章ID"})
+			return
+		}
+		seen[id] = true
+	}
+
+	tx := config.DB.Begin()
+
+	for i, id := range req.PostIDs {
+		if err := tx.Model(&models.SeriesPost{}).
+			Where("series_id = ? AND post_id = ?", series.ID, id).
+			Update("position", i+1).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "调整文章顺序失败: " + err.Error()})
+			return
+		}
+	}
+
+	tx.Commit()
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "文章顺序已更新",
+	})
+}
+
+// 加载文章所在系列的导航信息，文章不属于任何系列时返回nil
+func loadSeriesNavigation(postID uint) *models.SeriesNavigation {
+	var item models.SeriesPost
+	if err := config.DB.Where("post_id = ?", postID).First(&item).Error; err != nil {
+		return nil
+	}
+
+	var series models.Series
+	if err := config.DB.First(&series, item.SeriesID).Error; err != nil {
+		return nil
+	}
+
+	// 目录只包含已发布文章，当前文章始终保留
+	var entries []models.SeriesEntry
+	config.DB.Model(&models.SeriesPost{}).
+		Select("series_posts.post_id, posts.title, series_posts.position").
+		Joins("JOIN posts ON posts.id = series_posts.post_id AND posts.deleted_at IS NULL").
+		Where("series_posts.series_id = ? AND ((posts.status = ? AND posts.visibility <> ?) OR posts.id = ?)",
+			series.ID, "published", models.VisibilityPrivate, postID).
+		Order("series_posts.position ASC").
+		Scan(&entries)
+
+	nav := &models.SeriesNavigation{
+		ID:       series.ID,
+		Title:    series.Title,
+		Position: item.Position,
+		Total:    len(entries),
+		Contents: entries,
+	}
+
+	for i := range entries {
+		if entries[i].PostID != postID {
+			continue
+		}
+		if i > 0 {
+			prev := entries[i-1]
+			nav.Prev = &prev
+		}
+		if i < len(entries)-1 {
+			next := entries[i+1]
+			nav.Next = &next
+		}
+		break
+	}
+
+	return nav
+}
+
+// 检查当前用户是否可以管理系列（系列作者或管理员）
+func canManageSeries(c *gin.Context, series *models.Series) bool {
+	user, exists := c.Get("user")
+	if !exists {
+		return false
+	}
+	userModel, ok := user.(models.User)
+	if !ok {
+		return false
+	}
+	return series.UserID == userModel.ID || userModel.Role == "admin"
+}
+
+// 获取系列中全部文章ID
+func seriesPostIDs(db *gorm.DB, seriesID uint) []uint {
+	var ids []uint
+	db.Model(&models.SeriesPost{}).Where("series_id = ?", seriesID).Order("position ASC").Pluck("post_id", &ids)
+	return ids
+}
@@ -0,0 +1,147 @@
+package controllers
+
+import (
+	"blog/config"
+	"blog/models"
+	"blog/services"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 创建或更新内容片段请求
+type SnippetRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description" binding:"max=200"`
+	Content     string `json:"content" binding:"required"`
+}
+
+// 展开公开列表中文章正文的片段短代码，与文章详情一致，传 raw=true 时保持原样
+// 作者自己的文章、回收站、审核队列等管理列表返回原始正文
+func expandPostShortcodes(c *gin.Context, posts []*models.Post) {
+	if c.Query("raw") == "true" {
+		return
+	}
+	contents := make([]*string, 0, len(posts))
+	for _, post := range posts {
+		contents = append(contents, &post.Content)
+	}
+	services.ExpandShortcodesAll(contents)
+}
+
+// 获取全部内容片段
+func GetSnippets(c *gin.Context) {
+	var snippets []models.Snippet
+	query := config.DB.Preload("User")
+	if keyword := strings.TrimSpace(c.Query("keyword")); keyword != "" {
+		query = query.Where("name ILIKE ? OR description ILIKE ?", "%"+keyword+"%", "%"+keyword+"%")
+	}
+	if err := query.Order("name ASC").Find(&snippets).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取内容片段失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": snippets,
+	})
+}
+
+// 创建内容片段（编辑和管理员）
+func CreateSnippet(c *gin.Context) {
+	var req SnippetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "验证失败: " + err.Error()})
+		return
+	}
+	if !isEditor(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "只有编辑和管理员可以管理内容片段"})
+		return
+	}
+	if !checkSnippetName(c, req.Name, 0) {
+		return
+	}
+
+	snippet := models.Snippet{
+		Name:        req.Name,
+		Description: req.Description,
+		Content:     req.Content,
+		UserID:      currentUser(c).ID,
+	}
+	if err := config.DB.Create(&snippet).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建内容片段失败"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, snippet)
+}
+
+// 更新内容片段（编辑和管理员），修改后引用它的文章在下次渲染时使用新内容
+func UpdateSnippet(c *gin.Context) {
+	var req SnippetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "验证失败: " + err.Error()})
+		return
+	}
+	if !isEditor(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "只有编辑和管理员可以管理内容片段"})
+		return
+	}
+
+	var snippet models.Snippet
+	if err := config.DB.First(&snippet, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "内容片段不存在"})
+		return
+	}
+	if !checkSnippetName(c, req.Name, snippet.ID) {
+		return
+	}
+
+	snippet.Name = req.Name
+	snippet.Description = req.Description
+	snippet.Content = req.Content
+	if err := config.DB.Select("name", "description", "content").Updates(&snippet).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新内容片段失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, snippet)
+}
+
+// 删除内容片段（编辑和管理员），文章中的引用会保持短代码原样
+func DeleteSnippet(c *gin.Context) {
+	if !isEditor(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "只有编辑和管理员可以管理内容片段"})
+		return
+	}
+
+	var snippet models.Snippet
+	if err := config.DB.First(&snippet, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "内容片段不存在"})
+		return
+	}
+	if err := config.DB.Delete(&snippet).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除内容片段失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "内容片段删除成功",
+	})
+}
+
+// 检查片段名称是否合法且未被其他片段使用，不满足时写入错误响应
+func checkSnippetName(c *gin.Context, name string, excludeID uint) bool {
+	if !models.IsValidSnippetName(name) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "片段名称只能包含小写字母、数字、下划线和连字符，最多50个字符"})
+		return false
+	}
+	var count int64
+	config.DB.Model(&models.Snippet{}).Where("name = ? AND id <> ?", name, excludeID).Count(&count)
+	if count > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "该片段名称已存在"})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,217 @@
+package controllers
+
+import (
+	"blog/config"
+	"blog/models"
+	"blog/services"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 创建或更新文章模板请求
+type PostTemplateRequest struct {
+	Name         string   `json:"name" binding:"required,max=100"`
+	Description  string   `json:"description" binding:"max=500"`
+	Site         bool     `json:"site"` // 创建站点模板，仅管理员，更新时忽略
+	TitlePattern string   `json:"titlePattern" binding:"max=200"`
+	Content      string   `json:"content"`
+	Summary      string   `json:"summary" binding:"max=500"`
+	Cover        string   `json:"cover" binding:"max=255"`
+	Tags         []string `json:"tags"`
+	CategoryID   *uint    `json:"categoryId"`
+}
+
+// 当前用户能否使用模板：站点模板所有人可用，个人模板只有本人和管理员可用
+func canUseTemplate(c *gin.Context, template *models.PostTemplate) bool {
+	user := currentUser(c)
+	if user == nil {
+		return false
+	}
+	return template.IsSite() || *template.UserID == user.ID || user.Role == "admin"
+}
+
+// 当前用户能否修改模板：站点模板只有管理员可以修改，个人模板本人和管理员可以修改
+func canManageTemplate(c *gin.Context, template *models.PostTemplate) bool {
+	user := currentUser(c)
+	if user == nil {
+		return false
+	}
+	return user.Role == "admin" || (!template.IsSite() && *template.UserID == user.ID)
+}
+
+// 查找当前用户可用的模板，不存在或无权使用时写入错误响应
+func findPostTemplate(c *gin.Context, id interface{}) (models.PostTemplate, bool) {
+	var template models.PostTemplate
+	if err := config.DB.Preload("Category").First(&template, id).Error; err != nil || !canUseTemplate(c, &template) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "模板不存在"})
+		return template, false
+	}
+	return template, true
+}
+
+// 把模板内容填入创建文章请求中未指定的字段
+func applyPostTemplate(req *CreatePostRequest, template *models.PostTemplate, user *models.User) {
+	if req.Title == "" {
+		req.Title = services.RenderTitlePattern(template, user, time.Now())
+	}
+	if req.Content == "" {
+		req.Content = template.Content
+	}
+	if req.Summary == "" {
+		req.Summary = template.Summary
+	}
+	if req.Cover == "" && req.CoverMediaID == nil {
+		req.Cover = template.Cover
+	}
+	if req.Tags == nil {
+		req.Tags = template.Tags
+	}
+	if req.CategoryIDs == nil && template.CategoryID != nil {
+		req.CategoryIDs = []uint{*template.CategoryID}
+	}
+}
+
+// 获取可用的文章模板：站点模板和自己的模板，scope 可选 site 或 mine
+func GetPostTemplates(c *gin.Context) {
+	user := currentUser(c)
+
+	query := config.DB.Preload("Category")
+	switch c.Query("scope") {
+	case "site":
+		query = query.Where("user_id IS NULL")
+	case "mine":
+		query = query.Where("user_id = ?", user.ID)
+	default:
+		query = query.Where("user_id IS NULL OR user_id = ?", user.ID)
+	}
+
+	var templates []models.PostTemplate
+	if err := query.Order("user_id NULLS FIRST, name ASC").Find(&templates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取模板失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": templates,
+	})
+}
+
+// 获取模板详情，附带按当前时间生成的标题预览
+func GetPostTemplate(c *gin.Context) {
+	template, ok := findPostTemplate(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":         template,
+		"titlePreview": services.RenderTitlePattern(&template, currentUser(c), time.Now()),
+	})
+}
+
+// 创建文章模板，站点模板仅管理员可以创建
+func CreatePostTemplate(c *gin.Context) {
+	var req PostTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "验证失败: " + err.Error()})
+		return
+	}
+
+	user := currentUser(c)
+	template := models.PostTemplate{}
+	if req.Site {
+		if user.Role != "admin" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "只有管理员可以创建站点模板"})
+			return
+		}
+	} else {
+		template.UserID = &user.ID
+	}
+	if !fillPostTemplate(c, &template, &req) {
+		return
+	}
+
+	if err := config.DB.Omit("Category").Create(&template).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建模板失败"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, template)
+}
+
+// 更新文章模板
+func UpdatePostTemplate(c *gin.Context) {
+	var req PostTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "验证失败: " + err.Error()})
+		return
+	}
+
+	template, ok := findPostTemplate(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	if !canManageTemplate(c, &template) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权修改此模板"})
+		return
+	}
+	if !fillPostTemplate(c, &template, &req) {
+		return
+	}
+
+	if err := config.DB.Omit("User", "Category").Save(&template).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新模板失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+// 删除文章模板，已用模板创建的文章不受影响
+func DeletePostTemplate(c *gin.Context) {
+	template, ok := findPostTemplate(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	if !canManageTemplate(c, &template) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权删除此模板"})
+		return
+	}
+
+	if err := config.DB.Delete(&template).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除模板失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "模板删除成功",
+	})
+}
+
+// 把请求内容写入模板，默认分类不存在时写入错误响应
+func fillPostTemplate(c *gin.Context, template *models.PostTemplate, req *PostTemplateRequest) bool {
+	template.Category = nil
+	if req.CategoryID != nil && *req.CategoryID != 0 {
+		categories, err := findCategoriesByIDs([]uint{*req.CategoryID})
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return false
+		}
+		template.Category = &categories[0]
+		template.CategoryID = &categories[0].ID
+	} else {
+		template.CategoryID = nil
+	}
+
+	template.Name = req.Name
+	template.Description = req.Description
+	template.TitlePattern = req.TitlePattern
+	template.Content = req.Content
+	template.Summary = req.Summary
+	template.Cover = req.Cover
+	template.Tags = req.Tags
+	return true
+}
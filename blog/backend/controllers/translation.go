@@ -0,0 +1,156 @@
+package controllers
+
+import (
+	"blog/config"
+	"blog/models"
+	"blog/utils"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 解析并校验文章语言，为空时使用默认语言
+func normalizePostLanguage(lang string) (string, error) {
+	if lang == "" {
+		return models.DefaultLanguage, nil
+	}
+	lang = utils.NormalizeLanguage(lang)
+	if !models.IsSupportedLanguage(lang) {
+		return "", errors.New("不支持的语言: " + lang)
+	}
+	return lang, nil
+}
+
+// 当前请求偏好的文章语言：依次取 Accept-Language 中第一个支持的语言，否则为默认语言
+func preferredLanguage(c *gin.Context) string {
+	for _, lang := range utils.ParseAcceptLanguage(c.GetHeader("Accept-Language")) {
+		if models.IsSupportedLanguage(lang) {
+			return lang
+		}
+	}
+	return models.DefaultLanguage
+}
+
+// 查找要加入的翻译组，返回原文ID。原文必须可由当前用户编辑，且翻译组中不能已有相同语言的版本
+// excludeID 为正在修改的文章自身，新建文章时传0
+func resolveTranslationGroup(c *gin.Context, targetID uint, lang string, excludeID uint) (uint, error) {
+	var target models.Post
+	if err := config.DB.First(&target, targetID).Error; err != nil {
+		return 0, errors.New("原文不存在")
+	}
+	if !canEditPost(c, &target) {
+		return 0, errors.New("无权为该文章添加译文")
+	}
+
+	root := target.TranslationGroup()
+	if root == excludeID {
+		return 0, errors.New("文章不能作为自己的译文")
+	}
+
+	if err := checkTranslationLanguage(root, lang, excludeID); err != nil {
+		return 0, err
+	}
+	return root, nil
+}
+
+// 检查翻译组中除 excludeID 外是否已有该语言的版本
+func checkTranslationLanguage(root uint, lang string, excludeID uint) error {
+	var count int64
+	config.DB.Model(&models.Post{}).
+		Where("(id = ? OR translation_of = ?) AND language = ? AND id <> ?", root, root, lang, excludeID).
+		Count(&count)
+	if count > 0 {
+		return errors.New("该翻译组已有此语言的版本")
+	}
+	return nil
+}
+
+// 同一语言下 slug 重复
+var errSlugConflict = errors.New("该语言下已存在相同的slug")
+
+// 检查同一语言下 slug 是否已被其他未删除的文章使用，与唯一索引 idx_posts_language_slug 的范围一致
+// 并发写入时仍可能冲突，由唯一索引兜底，见 isSlugConflict
+func checkSlugAvailable(slug, lang string, excludeID uint) error {
+	if slug == "" {
+		return nil
+	}
+	var count int64
+	config.DB.Model(&models.Post{}).
+		Where("slug = ? AND language = ? AND id <> ?", slug, lang, excludeID).
+		Count(&count)
+	if count > 0 {
+		return errSlugConflict
+	}
+	return nil
+}
+
+// 写入文章时的错误是否为 slug 唯一索引冲突（文章表上只有这一个唯一约束）
+func isSlugConflict(err error) bool {
+	return errors.Is(err, errSlugConflict) || errors.Is(err, gorm.ErrDuplicatedKey)
+}
+
+// 为文章附加同一翻译组中当前用户可见的其他语言版本
+func attachTranslations(c *gin.Context, post *models.Post) {
+	root := post.TranslationGroup()
+
+	var posts []models.Post
+	scopeVisiblePosts(c, config.DB.Model(&models.Post{}), "all").
+		Select("posts.id, posts.language, posts.title, posts.slug").
+		Where("(posts.id = ? OR posts.translation_of = ?) AND posts.id <> ?", root, root, post.ID).
+		Order("posts.language ASC").
+		Find(&posts)
+
+	post.Translations = make([]models.PostTranslation, 0, len(posts))
+	for _, translation := range posts {
+		post.Translations = append(post.Translations, models.PostTranslation{
+			ID:       translation.ID,
+			Language: translation.Language,
+			Title:    translation.Title,
+			Slug:     translation.Slug,
+		})
+	}
+}
+
+// 根据 slug 获取文章，lang 参数指定语言，未指定时按 Accept-Language 选择，找不到时回退到其他语言的版本
+func GetPostBySlug(c *gin.Context) {
+	slug := c.Param("slug")
+
+	lang := preferredLanguage(c)
+	explicit := c.Query("lang") != ""
+	if explicit {
+		var err error
+		if lang, err = normalizePostLanguage(c.Query("lang")); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	var posts []models.Post
+	scopeVisiblePosts(c, config.DB.Model(&models.Post{}), "all").
+		Select("posts.id, posts.language").
+		Where("posts.slug = ?", slug).
+		Order("posts.id ASC").
+		Find(&posts)
+
+	// 优先返回请求的语言，显式指定 lang 时不回退
+	var found uint
+	for _, post := range posts {
+		if post.Language == lang {
+			found = post.ID
+			break
+		}
+	}
+	if found == 0 && !explicit && len(posts) > 0 {
+		found = posts[0].ID
+	}
+	if found == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "文章不存在"})
+		return
+	}
+
+	c.Params = append(c.Params, gin.Param{Key: "id", Value: strconv.FormatUint(uint64(found), 10)})
+	GetPost(c)
+}
@@ -0,0 +1,276 @@
+package controllers
+
+import (
+	"blog/config"
+	"blog/models"
+	"blog/services"
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 获取当前用户回收站中的文章或评论
+func GetUserTrash(c *gin.Context) {
+	user, _ := c.Get("user")
+	userModel := user.(models.User)
+	listTrash(c, &userModel.ID)
+}
+
+// 获取全站回收站中的文章或评论（管理员）
+func GetAdminTrash(c *gin.Context) {
+	listTrash(c, nil)
+}
+
+// 回收站列表，type=post（默认）或 comment；userID为空时不按用户过滤
+func listTrash(c *gin.Context, userID *uint) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 50 {
+		pageSize = 10
+	}
+	offset := (page - 1) * pageSize
+
+	retentionDays := services.TrashRetentionDays()
+
+	switch c.DefaultQuery("type", "post") {
+	case "post":
+		query := config.DB.Unscoped().Model(&models.Post{}).Where("deleted_at IS NOT NULL")
+		if userID != nil {
+			query = query.Where("user_id = ?", *userID)
+		}
+
+		var total int64
+		query.Count(&total)
+
+		var posts []models.Post
+		query.Preload("User").Preload("Tags").Preload("Categories").
+			Order("deleted_at DESC, id DESC").
+			Offset(offset).Limit(pageSize).
+			Find(&posts)
+
+		items := make([]gin.H, 0, len(posts))
+		for _, post := range posts {
+			items = append(items, gin.H{
+				"post":      post,
+				"deletedAt": post.DeletedAt.Time,
+				"purgeAt":   post.DeletedAt.Time.AddDate(0, 0, retentionDays),
+			})
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"data":          items,
+			"total":         total,
+			"page":          page,
+			"size":          pageSize,
+			"retentionDays": retentionDays,
+		})
+	case "comment":
+		query := config.DB.Unscoped().Model(&models.Comment{}).Where("deleted_at IS NOT NULL")
+		if userID != nil {
+			query = query.Where("user_id = ?", *userID)
+		}
+
+		var total int64
+		query.Count(&total)
+
+		var comments []models.Comment
+		query.Preload("User").
+			Order("deleted_at DESC, id DESC").
+			Offset(offset).Limit(pageSize).
+			Find(&comments)
+
+		// 附加所属文章的标题（文章本身可能也已删除）
+		postIDs := make([]uint, 0, len(comments))
+		for _, comment := range comments {
+			postIDs = append(postIDs, comment.PostID)
+		}
+		var posts []models.Post
+		if len(postIDs) > 0 {
+			config.DB.Unscoped().Select("id, title, deleted_at").Where("id IN ?", postIDs).Find(&posts)
+		}
+		postsByID := make(map[uint]models.Post, len(posts))
+		for _, post := range posts {
+			postsByID[post.ID] = post
+		}
+
+		items := make([]gin.H, 0, len(comments))
+		for _, comment := range comments {
+			post := postsByID[comment.PostID]
+			items = append(items, gin.H{
+				"comment":     comment,
+				"postTitle":   post.Title,
+				"postDeleted": post.DeletedAt.Valid,
+				"deletedAt":   comment.DeletedAt.Time,
+				"purgeAt":     comment.DeletedAt.Time.AddDate(0, 0, retentionDays),
+			})
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"data":          items,
+			"total":         total,
+			"page":          page,
+			"size":          pageSize,
+			"retentionDays": retentionDays,
+		})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type 只能是 post 或 comment"})
+	}
+}
+
+// 从回收站恢复文章
+func RestoreTrashPost(c *gin.Context) {
+	post, ok := findTrashPost(c)
+	if !ok {
+		return
+	}
+
+	// 删除期间 slug 可能已被同一语言的其他文章使用
+	if err := checkSlugAvailable(post.Slug, post.Language, post.ID); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error() + "，请先修改其中一篇文章的slug"})
+		return
+	}
+
+	if err := config.DB.Unscoped().Model(post).Update("deleted_at", nil).Error; err != nil {
+		if isSlugConflict(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": errSlugConflict.Error() + "，请先修改其中一篇文章的slug"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "恢复文章失败: " + err.Error()})
+		return
+	}
+
+	// 删除可能残留的详情缓存
+	config.Redis.Del(context.Background(), services.PostCacheKey(post.ID))
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "文章已恢复",
+		"id":      post.ID,
+	})
+}
+
+// 从回收站恢复评论，同时恢复随其一起删除的回复和通知
+func RestoreTrashComment(c *gin.Context) {
+	comment, ok := findTrashComment(c)
+	if !ok {
+		return
+	}
+
+	// 所属文章或父评论仍在回收站中时无法单独恢复
+	var post models.Post
+	if err := config.DB.Select("id").First(&post, comment.PostID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "评论所属的文章已删除，请先恢复文章"})
+		return
+	}
+	if comment.ParentID != nil {
+		var parent models.Comment
+		if err := config.DB.Select("id").First(&parent, *comment.ParentID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "父评论已删除，请先恢复父评论"})
+			return
+		}
+	}
+
+	deletedAt := comment.DeletedAt.Time
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		// 删除评论时回复与通知使用了相同的删除时间，据此识别级联删除的数据
+		var replyIDs []uint
+		if err := tx.Unscoped().Model(&models.Comment{}).
+			Where("parent_id = ? AND deleted_at = ?", comment.ID, deletedAt).
+			Pluck("id", &replyIDs).Error; err != nil {
+			return err
+		}
+
+		ids := append(replyIDs, comment.ID)
+		if err := tx.Unscoped().Model(&models.Comment{}).Where("id IN ?", ids).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Model(&models.Notification{}).
+			Where("comment_id IN ? AND deleted_at = ?", ids, deletedAt).
+			Update("deleted_at", nil).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "恢复评论失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "评论已恢复",
+		"id":      comment.ID,
+	})
+}
+
+// 永久删除回收站中的文章
+func PurgeTrashPost(c *gin.Context) {
+	post, ok := findTrashPost(c)
+	if !ok {
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		return services.PurgePosts(tx, []uint{post.ID})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "永久删除文章失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "文章已永久删除"})
+}
+
+// 永久删除回收站中的评论及其回复
+func PurgeTrashComment(c *gin.Context) {
+	comment, ok := findTrashComment(c)
+	if !ok {
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		return services.PurgeComments(tx, []uint{comment.ID})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "永久删除评论失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "评论已永久删除"})
+}
+
+// 查找回收站中的文章并检查权限（作者或管理员），失败时写入错误响应
+func findTrashPost(c *gin.Context) (*models.Post, bool) {
+	var post models.Post
+	if err := config.DB.Unscoped().Where("deleted_at IS NOT NULL").First(&post, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "回收站中不存在该文章"})
+		return nil, false
+	}
+
+	if !isPostOwnerOrAdmin(c, &post) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权操作此文章"})
+		return nil, false
+	}
+
+	return &post, true
+}
+
+// 查找回收站中的评论并检查权限（评论者或管理员），失败时写入错误响应
+func findTrashComment(c *gin.Context) (*models.Comment, bool) {
+	var comment models.Comment
+	if err := config.DB.Unscoped().Where("deleted_at IS NOT NULL").First(&comment, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "回收站中不存在该评论"})
+		return nil, false
+	}
+
+	user := currentUser(c)
+	if user == nil || (user.ID != comment.UserID && user.Role != "admin") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权操作此评论"})
+		return nil, false
+	}
+
+	return &comment, true
+}
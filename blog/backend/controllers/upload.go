@@ -0,0 +1,55 @@
+package controllers
+
+import (
+	"blog/config"
+	"blog/storage"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 跳转到对象存储时预签名链接的有效期
+const uploadRedirectTTL = 15 * time.Minute
+
+// 访问上传的文件
+// 本地存储直接返回文件，私有对象需要带有效的签名；对象存储时跳转到预签名链接（私有对象应直接使用存储生成的签名链接）
+func ServeUpload(c *gin.Context) {
+	key, err := storage.CleanKey(c.Param("filepath"))
+	if err != nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+
+	local, ok := config.Storage.(*storage.LocalStorage)
+	if !ok {
+		if storage.IsPrivate(key) {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		signedURL, err := config.Storage.SignedURL(key, uploadRedirectTTL)
+		if err != nil {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		c.Redirect(http.StatusFound, signedURL)
+		return
+	}
+
+	if storage.IsPrivate(key) && !local.VerifySignature(key, c.Query("expires"), c.Query("signature")) {
+		c.Status(http.StatusForbidden)
+		return
+	}
+
+	filePath, err := local.Path(key)
+	if err != nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	if info, err := os.Stat(filePath); err != nil || info.IsDir() {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	c.File(filePath)
+}
@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	"blog/config"
+	"blog/models"
+	"blog/services"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 获取文章最近若干天的每日浏览量和独立访客数（作者和管理员），用于绘制图表
+// days默认30，最多365；没有浏览的日期补0，今天的数据包含尚未写入数据库的浏览量
+func GetPostViewStats(c *gin.Context) {
+	var post models.Post
+	if err := config.DB.Select("id, user_id, view_count").First(&post, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "文章不存在"})
+		return
+	}
+	if !isPostAuthor(c, &post) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权查看此文章的统计"})
+		return
+	}
+
+	days, _ := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if days < 1 || days > 365 {
+		days = 30
+	}
+	now := time.Now()
+	start := now.AddDate(0, 0, 1-days)
+
+	var stats []models.PostViewDaily
+	config.DB.Where("post_id = ? AND date >= ?", post.ID, start.Format("2006-01-02")).Order("date").Find(&stats)
+	byDay := make(map[string]models.PostViewDaily, len(stats))
+	for _, stat := range stats {
+		byDay[stat.Date.Format("2006-01-02")] = stat
+	}
+
+	today := now.Format("2006-01-02")
+	pending := services.PendingViews(post.ID)
+
+	var totalViews uint
+	items := make([]gin.H, 0, days)
+	for i := 0; i < days; i++ {
+		day := start.AddDate(0, 0, i).Format("2006-01-02")
+		stat := byDay[day]
+		if day == today {
+			stat.Views += pending
+			if visitors := services.DailyVisitors(post.ID, day); visitors > stat.Visitors {
+				stat.Visitors = visitors
+			}
+		}
+		totalViews += stat.Views
+		items = append(items, gin.H{
+			"date":     day,
+			"views":    stat.Views,
+			"visitors": stat.Visitors,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"postId":     post.ID,
+		"viewCount":  post.ViewCount + pending,
+		"rangeViews": totalViews,
+		"data":       items,
+	})
+}
@@ -0,0 +1,151 @@
+package controllers
+
+import (
+	"blog/config"
+	"blog/models"
+	"blog/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 解锁文章请求
+type UnlockPostRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// 可以出现在公开列表（首页、标签、分类、搜索等）中的可见性；
+// 密码保护的文章会列出标题，但内容被隐藏
+var listedVisibilities = []string{models.VisibilityPublic, models.VisibilityPassword}
+
+// 使用密码解锁文章，返回短期有效的访问令牌
+func UnlockPost(c *gin.Context) {
+	var req UnlockPostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "验证失败: " + err.Error()})
+		return
+	}
+
+	var post models.Post
+	if err := config.DB.First(&post, c.Param("id")).Error; err != nil || post.Status != "published" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "文章不存在"})
+		return
+	}
+
+	if post.Visibility != models.VisibilityPassword {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "该文章不需要密码"})
+		return
+	}
+
+	if !post.CheckAccessPassword(req.Password) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "密码不正确"})
+		return
+	}
+
+	token, err := utils.GeneratePostAccessToken(post.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成访问令牌失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"accessToken": token,
+		"expiresIn":   int(utils.PostAccessTokenTTL.Seconds()),
+	})
+}
+
+// 获取当前登录用户，未登录时返回nil
+func currentUser(c *gin.Context) *models.User {
+	user, exists := c.Get("user")
+	if !exists {
+		return nil
+	}
+	userModel, ok := user.(models.User)
+	if !ok {
+		return nil
+	}
+	return &userModel
+}
+
+// 当前用户是否为文章作者或管理员
+func isPostOwnerOrAdmin(c *gin.Context, post *models.Post) bool {
+	user := currentUser(c)
+	return user != nil && (user.ID == post.UserID || user.Role == "admin")
+}
+
+// 请求是否携带了该文章有效的访问令牌（请求头 X-Post-Token 或查询参数 accessToken）
+func hasPostAccessToken(c *gin.Context, postID uint) bool {
+	token := c.GetHeader("X-Post-Token")
+	if token == "" {
+		token = c.Query("accessToken")
+	}
+	if token == "" {
+		return false
+	}
+	claims, err := utils.ParsePostAccessToken(token)
+	return err == nil && claims.PostID == postID
+}
+
+// 检查当前请求能否查看文章，不能查看时写入错误响应并返回false
+func checkPostAccess(c *gin.Context, post *models.Post) bool {
+	// 作者可以查看自己的全部文章，编辑和管理员可以查看审核中的文章
+	if isPostAuthor(c, post) || (inReview(post) && isEditor(c)) {
+		return true
+	}
+
+	// 草稿和私密文章对其他人表现为不存在
+	if post.Status != "published" || post.Visibility == models.VisibilityPrivate {
+		c.JSON(http.StatusNotFound, gin.H{"error": "文章不存在"})
+		return false
+	}
+
+	if post.Visibility == models.VisibilityPassword && !hasPostAccessToken(c, post.ID) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":            "该文章需要密码访问",
+			"passwordRequired": true,
+			"id":               post.ID,
+			"title":            post.Title,
+		})
+		return false
+	}
+
+	return true
+}
+
+// 限制文章列表只包含当前用户可见的文章
+// status为"all"时不按状态过滤；管理员不受限制，作者可以看到自己（含共同撰写）的全部文章
+func scopeVisiblePosts(c *gin.Context, query *gorm.DB, status string) *gorm.DB {
+	if status != "all" {
+		query = query.Where("posts.status = ?", status)
+	}
+
+	user := currentUser(c)
+	if user != nil && user.Role == "admin" {
+		return query
+	}
+
+	listed := config.DB.Where("posts.status = ? AND posts.visibility IN ?", "published", listedVisibilities)
+	if user != nil {
+		return query.Where(listed.Or("posts.user_id = ?", user.ID).Or("posts.id IN (?)", acceptedAuthorPostIDs(user.ID)))
+	}
+	return query.Where(listed)
+}
+
+// 只保留可以公开列出的已发布文章
+func scopeListedPosts(query *gorm.DB) *gorm.DB {
+	return query.Where("posts.status = ? AND posts.visibility IN ?", "published", listedVisibilities)
+}
+
+// 隐藏列表中密码保护文章的内容（作者和管理员除外）
+func redactProtectedPosts(c *gin.Context, posts []*models.Post) {
+	for _, post := range posts {
+		if post.Visibility != models.VisibilityPassword || isPostAuthor(c, post) {
+			continue
+		}
+		post.Content = ""
+		post.Summary = ""
+		post.TOC = nil
+		post.Locked = true
+	}
+}
@@ -1,70 +1,88 @@
-package middlewares
-
-import (
-	"blog/models"
-	"blog/utils"
-	"net/http"
-	"strings"
-
-	"github.com/gin-gonic/gin"
-)
-
-// JWT认证中间件
-func AuthMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// 从请求头获取JWT令牌
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "未提供授权令牌"})
-			c.Abort()
-			return
-		}
-
-		// 通常令牌格式为"Bearer {token}"
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "授权令牌格式无效"})
-			c.Abort()
-			return
-		}
-
-		// 验证令牌
-		tokenString := parts[1]
-		claims, err := utils.ParseToken(tokenString)
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "无效令牌: " + err.Error()})
-			c.Abort()
-			return
-		}
-
-		// 设置用户信息到上下文
-		user := models.User{
-			ID:       claims.UserID,
-			Username: claims.Username,
-			Role:     claims.Role,
-		}
-		c.Set("user", user)
-		c.Next()
-	}
-}
-
-// 管理员权限中间件
-func AdminMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		user, exists := c.Get("user")
-		if !exists {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "未认证用户"})
-			c.Abort()
-			return
-		}
-
-		userModel, ok := user.(models.User)
-		if !ok || userModel.Role != "admin" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "需要管理员权限"})
-			c.Abort()
-			return
-		}
-
-		c.Next()
-	}
-}
+package middlewares
+
+import (
+	"blog/models"
+	"blog/utils"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JWT认证中间件
+func AuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// 从请求头获取JWT令牌
+		authHeader := c.GetHeader("Authorization")
+		if authHeader == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "未提供授权令牌"})
+			c.Abort()
+			return
+		}
+
+		// 通常令牌格式为"Bearer {token}"
+		parts := strings.Split(authHeader, " ")
+		if len(parts) != 2 || parts[0] != "Bearer" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "授权令牌格式无效"})
+			c.Abort()
+			return
+		}
+
+		// 验证令牌
+		tokenString := parts[1]
+		claims, err := utils.ParseToken(tokenString)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "无效令牌: " + err.Error()})
+			c.Abort()
+			return
+		}
+
+		// 设置用户信息到上下文
+		user := models.User{
+			ID:       claims.UserID,
+			Username: claims.Username,
+			Role:     claims.Role,
+		}
+		c.Set("user", user)
+		c.Next()
+	}
+}
+
+// 可选认证中间件：携带有效令牌时设置用户信息，否则以游客身份继续
+func OptionalAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authHeader := c.GetHeader("Authorization")
+		parts := strings.Split(authHeader, " ")
+		if len(parts) == 2 && parts[0] == "Bearer" {
+			if claims, err := utils.ParseToken(parts[1]); err == nil {
+				c.Set("user", models.User{
+					ID:       claims.UserID,
+					Username: claims.Username,
+					Role:     claims.Role,
+				})
+			}
+		}
+		c.Next()
+	}
+}
+
+// 管理员权限中间件
+func AdminMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, exists := c.Get("user")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "未认证用户"})
+			c.Abort()
+			return
+		}
+
+		userModel, ok := user.(models.User)
+		if !ok || userModel.Role != "admin" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "需要管理员权限"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
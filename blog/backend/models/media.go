@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+)
+
+// 媒体文件的衍生尺寸
+const (
+	MediaVariantThumb  = "thumb"  // 缩略图，用于列表
+	MediaVariantMedium = "medium" // 中图，用于正文
+	MediaVariantLarge  = "large"  // 大图，用于封面
+)
+
+// 媒体文件模型，删除时同时删除文件，因此不使用软删除
+type Media struct {
+	ID        uint                    `json:"id" gorm:"primaryKey"`
+	UserID    uint                    `json:"userId" gorm:"not null;index"`
+	User      User                    `json:"-" gorm:"foreignKey:UserID"`
+	FileName  string                  `json:"fileName" gorm:"size:255"` // 上传时的原始文件名
+	Alt       string                  `json:"alt" gorm:"size:500"`      // 替代文本，最多500个字符
+	MimeType  string                  `json:"mimeType" gorm:"size:50;index"`
+	Size      int64                   `json:"size"`
+	Width     int                     `json:"width"`
+	Height    int                     `json:"height"`
+	Path      string                  `json:"-" gorm:"size:255;not null"` // 文件在存储中的路径
+	URL       string                  `json:"url" gorm:"size:255;not null"`
+	Variants  map[string]MediaVariant `json:"variants" gorm:"serializer:json;type:text"` // 按名称索引的衍生尺寸
+	CreatedAt time.Time               `json:"createdAt"`
+	UpdatedAt time.Time               `json:"updatedAt"`
+}
+
+// 媒体文件的衍生尺寸
+type MediaVariant struct {
+	Path   string `json:"path"`
+	URL    string `json:"url"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+// 返回指定尺寸的链接，没有该尺寸（原图更小）时返回原图链接
+func (m *Media) VariantURL(name string) string {
+	if variant, ok := m.Variants[name]; ok {
+		return variant.URL
+	}
+	return m.URL
+}
@@ -1,35 +1,183 @@
-package models
-
-import (
-	"time"
-
-	"gorm.io/gorm"
-)
-
-// 文章模型
-type Post struct {
-	ID        uint           `json:"id" gorm:"primaryKey"`
-	Title     string         `json:"title" gorm:"size:200;not null"`
-	Content   string         `json:"content" gorm:"type:text;not null"`
-	Summary   string         `json:"summary" gorm:"size:500"`
-	Cover     string         `json:"cover" gorm:"size:255"`
-	Status    string         `json:"status" gorm:"size:20;default:'draft'"` // draft, published
-	UserID    uint           `json:"userId" gorm:"not null"`
-	User      User           `json:"user" gorm:"foreignKey:UserID"`
-	Tags      []Tag          `json:"tags" gorm:"many2many:post_tags;"`
-	Comments  []Comment      `json:"comments,omitempty" gorm:"foreignKey:PostID"`
-	ViewCount uint           `json:"viewCount" gorm:"default:0"`
-	CreatedAt time.Time      `json:"createdAt"`
-	UpdatedAt time.Time      `json:"updatedAt"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
-}
-
-// 标签模型
-type Tag struct {
-	ID        uint           `json:"id" gorm:"primaryKey"`
-	Name      string         `json:"name" gorm:"uniqueIndex;size:50;not null"`
-	Posts     []Post         `json:"posts,omitempty" gorm:"many2many:post_tags;"`
-	CreatedAt time.Time      `json:"createdAt"`
-	UpdatedAt time.Time      `json:"updatedAt"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
-}
+package models
+
+import (
+	"blog/utils"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// 文章模型
+type Post struct {
+	ID            uint              `json:"id" gorm:"primaryKey"`
+	Title         string            `json:"title" gorm:"size:200;not null"`
+	Slug          string            `json:"slug,omitempty" gorm:"size:200;index;uniqueIndex:idx_posts_language_slug,where:slug <> '' AND deleted_at IS NULL,priority:2"` // URL别名，同一语言内唯一（不含已删除的文章），导入的文章保留原站点的slug
+	Language      string            `json:"language" gorm:"size:10;not null;default:'zh';uniqueIndex:idx_posts_language_slug,where:slug <> '' AND deleted_at IS NULL,priority:1;index"`
+	TranslationOf *uint             `json:"translationOf" gorm:"index"`      // 原文ID，为空表示本身是原文；原文和它的所有译文组成一个翻译组
+	Translations  []PostTranslation `json:"translations,omitempty" gorm:"-"` // 同一翻译组中其他语言的版本，不入库
+	Content       string            `json:"content" gorm:"type:text;not null"`
+	Summary       string            `json:"summary" gorm:"size:500"`
+	WordCount     int               `json:"wordCount" gorm:"default:0"`   // 字数，中日韩文字按字计，其他语言按词计
+	CharCount     int               `json:"charCount" gorm:"default:0"`   // 字符数，不含空白
+	ReadingTime   int               `json:"readingTime" gorm:"default:0"` // 预计阅读时间（分钟）
+	TOC           TableOfContents   `json:"toc" gorm:"type:text"`         // 按标题生成的目录
+	Cover         string            `json:"cover" gorm:"size:255"`
+	CoverMediaID  *uint             `json:"coverMediaId" gorm:"index"` // 封面引用的媒体文件，为空时Cover为外部链接
+	CoverMedia    *Media            `json:"coverMedia,omitempty" gorm:"foreignKey:CoverMediaID;constraint:OnDelete:SET NULL"`
+	Status        string            `json:"status" gorm:"size:20;default:'draft'"` // draft, pending_review, changes_requested, published
+	ReviewerID    *uint             `json:"reviewerId" gorm:"index"`               // 指定的审核人
+	Reviewer      *User             `json:"reviewer,omitempty" gorm:"foreignKey:ReviewerID"`
+	SubmittedAt   *time.Time        `json:"submittedAt"`                                      // 最近一次提交审核的时间
+	Visibility    string            `json:"visibility" gorm:"size:20;default:'public';index"` // public, unlisted, private, password
+	Password      string            `json:"-" gorm:"size:100"`                                // 访问密码哈希，仅password可见性使用
+	Pinned        bool              `json:"pinned" gorm:"default:false;index"`                // 是否置顶到首页
+	PinOrder      int               `json:"pinOrder" gorm:"default:0"`                        // 置顶顺序，越小越靠前
+	PinnedUntil   *time.Time        `json:"pinnedUntil"`                                      // 置顶到期时间，为空表示一直置顶
+	Featured      bool              `json:"featured" gorm:"default:false;index"`              // 是否为编辑推荐
+	FeaturedAt    *time.Time        `json:"featuredAt"`                                       // 设为推荐的时间
+	Locked        bool              `json:"locked,omitempty" gorm:"-"`                        // 内容是否因需要密码而被隐藏，不入库
+	UserID        uint              `json:"userId" gorm:"not null"`
+	User          User              `json:"user" gorm:"foreignKey:UserID"`
+	Authors       []PostAuthor      `json:"authors,omitempty" gorm:"-"` // 作者列表（所有者及已接受邀请的共同作者、审阅者），不入库
+	Tags          []Tag             `json:"tags" gorm:"many2many:post_tags;"`
+	Categories    []Category        `json:"categories" gorm:"many2many:post_categories;"`
+	Comments      []Comment         `json:"comments,omitempty" gorm:"foreignKey:PostID"`
+	ViewCount     uint              `json:"viewCount" gorm:"default:0"`
+	LikeCount     uint              `json:"likeCount" gorm:"default:0"`
+	Reactions     map[string]int64  `json:"reactions,omitempty" gorm:"-"` // 各类表态数量，不入库
+	MyReactions   []string          `json:"myReactions" gorm:"-"`         // 当前用户的表态（未登录时为null），不入库
+	Series        *SeriesNavigation `json:"series,omitempty" gorm:"-"`    // 所属系列的导航信息，不入库
+	CreatedAt     time.Time         `json:"createdAt"`
+	UpdatedAt     time.Time         `json:"updatedAt"`
+	DeletedAt     gorm.DeletedAt    `json:"-" gorm:"index"`
+}
+
+// 文章状态
+const (
+	PostStatusDraft            = "draft"             // 草稿
+	PostStatusPendingReview    = "pending_review"    // 已提交，等待审核
+	PostStatusChangesRequested = "changes_requested" // 审核人要求修改
+	PostStatusPublished        = "published"         // 已发布
+)
+
+// 文章语言
+const DefaultLanguage = "zh"
+
+// 支持的文章语言
+var SupportedLanguages = []string{"zh", "en"}
+
+// 是否为支持的文章语言
+func IsSupportedLanguage(lang string) bool {
+	for _, supported := range SupportedLanguages {
+		if lang == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// 翻译组中其他语言的版本
+type PostTranslation struct {
+	ID       uint   `json:"id"`
+	Language string `json:"language"`
+	Title    string `json:"title"`
+	Slug     string `json:"slug,omitempty"`
+}
+
+// 文章可见性
+const (
+	VisibilityPublic   = "public"   // 公开
+	VisibilityUnlisted = "unlisted" // 不公开列出，仅可通过链接访问
+	VisibilityPrivate  = "private"  // 仅作者和管理员可见
+	VisibilityPassword = "password" // 需要密码访问
+)
+
+// 文章目录，以JSON格式存储
+type TableOfContents []utils.TOCItem
+
+func (t TableOfContents) Value() (driver.Value, error) {
+	if t == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(t)
+	return string(data), err
+}
+
+func (t *TableOfContents) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*t = nil
+		return nil
+	case []byte:
+		return t.Scan(string(v))
+	case string:
+		if v == "" {
+			*t = nil
+			return nil
+		}
+		return json.Unmarshal([]byte(v), t)
+	}
+	return fmt.Errorf("无法解析文章目录: %T", value)
+}
+
+// 根据正文计算字数、阅读时间和目录
+func (p *Post) ComputeContentStats() {
+	stats := utils.AnalyzeMarkdown(p.Content)
+	p.WordCount = stats.WordCount
+	p.CharCount = stats.CharCount
+	p.ReadingTime = stats.ReadingTime
+	p.TOC = stats.TOC
+}
+
+// ContentStatsColumns 正文修改时需要同时更新的统计字段，用于按字段更新
+func ContentStatsColumns(content string) map[string]interface{} {
+	post := Post{Content: content}
+	post.ComputeContentStats()
+	return map[string]interface{}{
+		"word_count":   post.WordCount,
+		"char_count":   post.CharCount,
+		"reading_time": post.ReadingTime,
+		"toc":          post.TOC,
+	}
+}
+
+// 设置访问密码
+func (p *Post) SetAccessPassword(password string) error {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	p.Password = string(hashedPassword)
+	return nil
+}
+
+// 验证访问密码
+func (p *Post) CheckAccessPassword(password string) bool {
+	if p.Password == "" {
+		return false
+	}
+	err := bcrypt.CompareHashAndPassword([]byte(p.Password), []byte(password))
+	return err == nil
+}
+
+// 标签模型
+type Tag struct {
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	Name      string         `json:"name" gorm:"uniqueIndex;size:50;not null"`
+	Posts     []Post         `json:"posts,omitempty" gorm:"many2many:post_tags;"`
+	CreatedAt time.Time      `json:"createdAt"`
+	UpdatedAt time.Time      `json:"updatedAt"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// 翻译组ID，即原文的ID
+func (p *Post) TranslationGroup() uint {
+	if p.TranslationOf != nil {
+		return *p.TranslationOf
+	}
+	return p.ID
+}
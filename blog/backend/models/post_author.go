@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+)
+
+// 文章作者角色
+const (
+	AuthorRoleOwner    = "owner"    // 文章所有者，即 Post.UserID，不单独存储
+	AuthorRoleCoauthor = "coauthor" // 共同作者，可以编辑文章
+	AuthorRoleReviewer = "reviewer" // 审阅者，可以查看未发布的文章，但不能编辑
+)
+
+// 共同作者邀请状态
+const (
+	AuthorStatusPending  = "pending"
+	AuthorStatusAccepted = "accepted"
+	AuthorStatusDeclined = "declined"
+)
+
+// 文章的共同作者和审阅者，由所有者邀请，接受邀请后生效
+type PostAuthor struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	PostID      uint      `json:"postId" gorm:"not null;uniqueIndex:idx_post_author"`
+	Post        *Post     `json:"post,omitempty" gorm:"foreignKey:PostID"`
+	UserID      uint      `json:"userId" gorm:"not null;uniqueIndex:idx_post_author;index"`
+	User        User      `json:"user" gorm:"foreignKey:UserID"`
+	Role        string    `json:"role" gorm:"size:20;not null"`                     // coauthor, reviewer
+	Status      string    `json:"status" gorm:"size:20;not null;default:'pending'"` // pending, accepted, declined
+	InvitedByID uint      `json:"invitedById"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// 是否为可以邀请的角色
+func IsInvitableAuthorRole(role string) bool {
+	return role == AuthorRoleCoauthor || role == AuthorRoleReviewer
+}
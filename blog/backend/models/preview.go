@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+)
+
+// 草稿预览链接，持有链接的人无需登录即可查看未发布的文章
+// 令牌本身不入库，撤销或过期后令牌随之失效
+type PostPreviewLink struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	PostID      uint       `json:"postId" gorm:"not null;index"`
+	CreatedByID uint       `json:"createdById" gorm:"not null"`
+	CreatedBy   User       `json:"createdBy" gorm:"foreignKey:CreatedByID"`
+	Note        string     `json:"note" gorm:"size:200"` // 备注，如分享给谁
+	ExpiresAt   time.Time  `json:"expiresAt"`
+	RevokedAt   *time.Time `json:"revokedAt"`
+	LastUsedAt  *time.Time `json:"lastUsedAt"`
+	ViewCount   uint       `json:"viewCount" gorm:"default:0"`
+	CreatedAt   time.Time  `json:"createdAt"`
+}
+
+// 预览链接当前是否有效
+func (l *PostPreviewLink) Active() bool {
+	return l.RevokedAt == nil && time.Now().Before(l.ExpiresAt)
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+)
+
+// 表态类型
+const (
+	ReactionTypeLike      = "like"
+	ReactionTypeLove      = "love"
+	ReactionTypeLaugh     = "laugh"
+	ReactionTypeWow       = "wow"
+	ReactionTypeSad       = "sad"
+	ReactionTypeCelebrate = "celebrate"
+)
+
+// 支持的表态类型集合
+var ReactionTypes = []string{
+	ReactionTypeLike,
+	ReactionTypeLove,
+	ReactionTypeLaugh,
+	ReactionTypeWow,
+	ReactionTypeSad,
+	ReactionTypeCelebrate,
+}
+
+// 判断表态类型是否有效
+func IsValidReactionType(reactionType string) bool {
+	for _, t := range ReactionTypes {
+		if t == reactionType {
+			return true
+		}
+	}
+	return false
+}
+
+// 文章表态（点赞及表情回应），同一用户对同一文章的同一类型只能有一条
+type Reaction struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"userId" gorm:"not null;uniqueIndex:idx_reaction_user_post_type"`
+	User      User      `json:"-" gorm:"foreignKey:UserID"`
+	PostID    uint      `json:"postId" gorm:"not null;index;uniqueIndex:idx_reaction_user_post_type"`
+	Post      Post      `json:"-" gorm:"foreignKey:PostID"`
+	Type      string    `json:"type" gorm:"size:20;not null;uniqueIndex:idx_reaction_user_post_type"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// 文章表态计数（由Redis计数定期对账写入）
+type PostReactionCount struct {
+	PostID    uint      `json:"postId" gorm:"primaryKey"`
+	Type      string    `json:"type" gorm:"primaryKey;size:20"`
+	Count     int64     `json:"count" gorm:"not null;default:0"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
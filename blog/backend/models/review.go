@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+)
+
+// 审核记录的操作类型，为空表示普通的审核意见
+const (
+	ReviewActionSubmit         = "submit"          // 提交审核
+	ReviewActionWithdraw       = "withdraw"        // 撤回审核
+	ReviewActionApprove        = "approve"         // 审核通过并发布
+	ReviewActionRequestChanges = "request_changes" // 要求修改
+)
+
+// 审核意见，只有作者和审核人可见，与公开评论分开存储
+// 状态变化时也会记录一条带 Action 的审核记录，形成完整的审核历史
+type ReviewComment struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	PostID    uint      `json:"postId" gorm:"not null;index"`
+	UserID    uint      `json:"userId" gorm:"not null"`
+	User      User      `json:"user" gorm:"foreignKey:UserID"`
+	Action    string    `json:"action,omitempty" gorm:"size:20"`
+	Content   string    `json:"content" gorm:"type:text"`
+	Line      int       `json:"line" gorm:"default:0"` // 针对Markdown正文的行号，0表示针对整篇文章
+	Quote     string    `json:"quote" gorm:"size:500"` // 引用的原文片段
+	Resolved  bool      `json:"resolved" gorm:"default:false"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// 审核流程允许的状态变化；发布和取消发布不在此列，由发布权限单独控制
+var reviewTransitions = map[string][]string{
+	PostStatusDraft:            {PostStatusPendingReview},
+	PostStatusChangesRequested: {PostStatusPendingReview, PostStatusDraft},
+	PostStatusPendingReview:    {PostStatusDraft, PostStatusChangesRequested, PostStatusPublished},
+}
+
+// CanTransition 审核流程中能否从一个状态变为另一个状态
+func CanTransition(from, to string) bool {
+	for _, status := range reviewTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
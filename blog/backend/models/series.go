@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 系列模型（多篇文章组成的连载/教程）
+type Series struct {
+	ID          uint           `json:"id" gorm:"primaryKey"`
+	Title       string         `json:"title" gorm:"size:200;not null"`
+	Description string         `json:"description" gorm:"size:500"`
+	UserID      uint           `json:"userId" gorm:"not null;index"`
+	User        User           `json:"user" gorm:"foreignKey:UserID"`
+	Items       []SeriesPost   `json:"items,omitempty" gorm:"foreignKey:SeriesID"`
+	CreatedAt   time.Time      `json:"createdAt"`
+	UpdatedAt   time.Time      `json:"updatedAt"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// 系列与文章的关联，Position决定文章在系列中的顺序
+type SeriesPost struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	SeriesID  uint      `json:"seriesId" gorm:"not null;index"`
+	PostID    uint      `json:"postId" gorm:"not null;uniqueIndex"` // 一篇文章只能属于一个系列
+	Post      Post      `json:"post" gorm:"foreignKey:PostID"`
+	Position  int       `json:"position" gorm:"not null;default:0"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// 系列目录中的单个条目
+type SeriesEntry struct {
+	PostID   uint   `json:"postId"`
+	Title    string `json:"title"`
+	Position int    `json:"position"`
+}
+
+// 文章所在系列的导航信息（上一篇、下一篇及目录）
+type SeriesNavigation struct {
+	ID       uint          `json:"id"`
+	Title    string        `json:"title"`
+	Position int           `json:"position"`
+	Total    int           `json:"total"`
+	Prev     *SeriesEntry  `json:"prev"`
+	Next     *SeriesEntry  `json:"next"`
+	Contents []SeriesEntry `json:"contents"`
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// 文章模板，UserID为空表示站点模板（管理员维护，所有用户可用），否则为个人模板
+// 标题模板支持占位符，见 services.RenderTitlePattern
+type PostTemplate struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	Name         string     `json:"name" gorm:"size:100;not null"`
+	Description  string     `json:"description" gorm:"size:500"`
+	UserID       *uint      `json:"userId" gorm:"index"`
+	User         *User      `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	TitlePattern string     `json:"titlePattern" gorm:"size:200"`
+	Content      string     `json:"content" gorm:"type:text"`
+	Summary      string     `json:"summary" gorm:"size:500"`
+	Cover        string     `json:"cover" gorm:"size:255"`
+	Tags         StringList `json:"tags" gorm:"type:text"`
+	CategoryID   *uint      `json:"categoryId"`
+	Category     *Category  `json:"category,omitempty" gorm:"foreignKey:CategoryID"`
+	UseCount     int        `json:"useCount" gorm:"default:0"` // 已用于创建的文章数，标题中的 {{n}} 为下一篇的序号
+	CreatedAt    time.Time  `json:"createdAt"`
+	UpdatedAt    time.Time  `json:"updatedAt"`
+}
+
+// 是否为站点模板
+func (t *PostTemplate) IsSite() bool {
+	return t.UserID == nil
+}
+
+// 可复用的内容片段，在文章正文中以 {{< snippet 名称 >}} 引用，渲染时展开
+type Snippet struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Name        string    `json:"name" gorm:"uniqueIndex;size:50;not null"`
+	Description string    `json:"description" gorm:"size:200"`
+	Content     string    `json:"content" gorm:"type:text;not null"`
+	UserID      uint      `json:"userId" gorm:"not null"` // 创建者
+	User        User      `json:"user" gorm:"foreignKey:UserID"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// 片段名称只能包含小写字母、数字、下划线和连字符
+var snippetNamePattern = regexp.MustCompile(`^[a-z0-9_-]{1,50}$`)
+
+// 是否为合法的片段名称
+func IsValidSnippetName(name string) bool {
+	return snippetNamePattern.MatchString(name)
+}
+
+// 字符串列表，以JSON格式存储
+type StringList []string
+
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(l)
+	return string(data), err
+}
+
+func (l *StringList) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*l = nil
+		return nil
+	case []byte:
+		return l.Scan(string(v))
+	case string:
+		if v == "" {
+			*l = nil
+			return nil
+		}
+		return json.Unmarshal([]byte(v), l)
+	}
+	return fmt.Errorf("无法解析字符串列表: %T", value)
+}
@@ -0,0 +1,13 @@
+package models
+
+import (
+	"time"
+)
+
+// 文章每日浏览统计，由后台任务把Redis中缓冲的浏览量定期写入
+type PostViewDaily struct {
+	PostID   uint      `json:"postId" gorm:"primaryKey;autoIncrement:false"`
+	Date     time.Time `json:"date" gorm:"primaryKey;type:date"`
+	Views    uint      `json:"views" gorm:"not null;default:0"`    // 浏览量（同一访客在去重窗口内只计一次）
+	Visitors uint      `json:"visitors" gorm:"not null;default:0"` // 独立访客数（HyperLogLog估算）
+}
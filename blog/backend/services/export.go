@@ -0,0 +1,460 @@
+package services
+
+import (
+	"archive/zip"
+	"blog/config"
+	"blog/models"
+	"blog/storage"
+	"blog/utils"
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 静态站点每页显示的文章数
+const exportPageSize = 20
+
+// ExportOptions 导出选项
+type ExportOptions struct {
+	HTML       bool   // 同时生成静态HTML站点（site目录）
+	UploadsDir string // 上传文件目录，生成静态站点时复制到 site/uploads
+	SiteTitle  string // 静态站点标题
+}
+
+// ExportResult 导出统计
+type ExportResult struct {
+	Posts      int `json:"posts"`
+	Tags       int `json:"tags"`
+	Categories int `json:"categories"`
+	Uploads    int `json:"uploads"`
+}
+
+// 导出时写入的front matter，字段与导入时解析的字段一致
+type exportFrontMatter struct {
+	Title      string   `yaml:"title"`
+	Slug       string   `yaml:"slug"`
+	Lang       string   `yaml:"lang,omitempty"`
+	Date       string   `yaml:"date"`
+	LastMod    string   `yaml:"lastmod,omitempty"`
+	Author     string   `yaml:"author,omitempty"`
+	Tags       []string `yaml:"tags,omitempty"`
+	Categories []string `yaml:"categories,omitempty"`
+	Summary    string   `yaml:"summary,omitempty"`
+	Cover      string   `yaml:"cover,omitempty"`
+}
+
+// 导出文件的写入目标（目录或zip压缩包）
+type exportWriter interface {
+	WriteFile(name string, data []byte) error
+}
+
+type dirExportWriter string
+
+func (dir dirExportWriter) WriteFile(name string, data []byte) error {
+	target := filepath.Join(string(dir), filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(target, data, 0644)
+}
+
+type zipExportWriter struct {
+	*zip.Writer
+}
+
+func (w zipExportWriter) WriteFile(name string, data []byte) error {
+	f, err := w.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	return err
+}
+
+// ExportToDir 导出到目录：posts 下为Markdown文件，HTML为true时 site 下为静态站点
+func ExportToDir(dir string, opts ExportOptions) (*ExportResult, error) {
+	return exportBlog(dirExportWriter(dir), opts)
+}
+
+// ExportToZip 导出为zip压缩包，目录结构与ExportToDir相同
+func ExportToZip(w io.Writer, opts ExportOptions) (*ExportResult, error) {
+	archive := zip.NewWriter(w)
+	result, err := exportBlog(zipExportWriter{archive}, opts)
+	if err != nil {
+		archive.Close()
+		return nil, err
+	}
+	return result, archive.Close()
+}
+
+// 导出的文章及其文件名
+type exportedPost struct {
+	models.Post
+	Name string        // 文件名（不含扩展名），同时用作静态站点中的路径
+	HTML template.HTML // 渲染后的正文
+}
+
+// CheckExportOptions 检查导出选项：上传文件只能从本地存储复制，使用对象存储时不能复制到静态站点
+func CheckExportOptions(opts ExportOptions) error {
+	if !opts.HTML || opts.UploadsDir == "" {
+		return nil
+	}
+	if _, ok := config.Storage.(*storage.LocalStorage); !ok {
+		return errors.New("当前使用对象存储，上传文件不在本地磁盘，无法复制到静态站点")
+	}
+	return nil
+}
+
+// 导出所有已发布的公开和不公开列出的文章；私密和密码保护的文章不会导出
+func exportBlog(out exportWriter, opts ExportOptions) (*ExportResult, error) {
+	if err := CheckExportOptions(opts); err != nil {
+		return nil, err
+	}
+
+	var posts []models.Post
+	err := config.DB.Preload("User").Preload("Tags").Preload("Categories").
+		Where("status = ? AND visibility IN ?", "published", []string{models.VisibilityPublic, models.VisibilityUnlisted}).
+		Order("created_at DESC, id DESC").
+		Find(&posts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	exported := make([]*exportedPost, 0, len(posts))
+	used := make(map[string]bool, len(posts))
+	for i := range posts {
+		post := &exportedPost{Post: posts[i], Name: exportFileName(&posts[i], used)}
+		exported = append(exported, post)
+
+		data, err := marshalMarkdownPost(&post.Post, post.Name)
+		if err != nil {
+			return nil, err
+		}
+		if err := out.WriteFile("posts/"+post.Name+".md", data); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &ExportResult{Posts: len(exported)}
+	if opts.HTML {
+		if err := exportStaticSite(out, exported, opts, result); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// 生成带front matter的Markdown文件内容
+func marshalMarkdownPost(post *models.Post, name string) ([]byte, error) {
+	slug := post.Slug
+	if slug == "" {
+		slug = strings.TrimSuffix(name, "."+post.Language)
+	}
+
+	meta := exportFrontMatter{
+		Title:   post.Title,
+		Slug:    slug,
+		Lang:    post.Language,
+		Date:    post.CreatedAt.Format(time.RFC3339),
+		Author:  post.User.Username,
+		Summary: post.Summary,
+		Cover:   post.Cover,
+	}
+	if post.UpdatedAt.After(post.CreatedAt) {
+		meta.LastMod = post.UpdatedAt.Format(time.RFC3339)
+	}
+	for _, tag := range post.Tags {
+		meta.Tags = append(meta.Tags, tag.Name)
+	}
+	for _, category := range post.Categories {
+		meta.Categories = append(meta.Categories, category.Name)
+	}
+
+	front, err := yaml.Marshal(meta)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("---\n")
+	buf.Write(front)
+	buf.WriteString("---\n\n")
+	buf.WriteString(strings.TrimSpace(post.Content))
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
+
+// 文件名中不允许出现的字符
+var unsafeFileName = regexp.MustCompile(`[^\p{L}\p{N}._-]+`)
+
+// 根据slug生成不重复的文件名，没有slug时使用 post-{id}
+// 非默认语言的文章按 Hugo 的习惯加上语言后缀，如 hello.en，导入时可以识别
+func exportFileName(post *models.Post, used map[string]bool) string {
+	name := strings.Trim(unsafeFileName.ReplaceAllString(post.Slug, "-"), "-.")
+	if name == "" {
+		name = "post-" + strconv.FormatUint(uint64(post.ID), 10)
+	}
+	suffix := ""
+	if post.Language != "" && post.Language != models.DefaultLanguage {
+		suffix = "." + post.Language
+	}
+	if used[name+suffix] {
+		name += "-" + strconv.FormatUint(uint64(post.ID), 10)
+	}
+	name += suffix
+	used[name] = true
+	return name
+}
+
+// 静态站点的列表页数据
+type sitePage struct {
+	SiteTitle   string
+	Title       string
+	Posts       []*exportedPost
+	Post        *exportedPost
+	Terms       []siteTerm
+	PrevURL     string
+	NextURL     string
+	Generated   time.Time
+	IsPostPage  bool
+	IsTermsPage bool
+}
+
+// 标签或分类
+type siteTerm struct {
+	Name  string
+	URL   string
+	Count int
+}
+
+// 生成静态站点：首页（分页）、文章页、标签页和分类页，并复制上传文件
+func exportStaticSite(out exportWriter, posts []*exportedPost, opts ExportOptions, result *ExportResult) error {
+	siteTitle := opts.SiteTitle
+	if siteTitle == "" {
+		siteTitle = "博客"
+	}
+	now := time.Now()
+
+	render := func(name string, page sitePage) error {
+		page.SiteTitle = siteTitle
+		page.Generated = now
+		var buf bytes.Buffer
+		if err := siteTemplate.Execute(&buf, page); err != nil {
+			return err
+		}
+		return out.WriteFile("site/"+name, buf.Bytes())
+	}
+
+	// 文章页（不公开列出的文章也生成页面，但不出现在列表中）
+	var listed []*exportedPost
+	tagPosts := make(map[uint][]*exportedPost)
+	categoryPosts := make(map[uint][]*exportedPost)
+	tagNames := make(map[uint]string)
+	categoryNames := make(map[uint]string)
+	for _, post := range posts {
+		// 页面中展开片段短代码，导出的Markdown文件保留短代码原样
+		post.HTML = template.HTML(utils.RenderMarkdown(ExpandShortcodes(post.Content)))
+		if err := render("posts/"+post.Name+"/index.html", sitePage{Title: post.Title, Post: post, IsPostPage: true}); err != nil {
+			return err
+		}
+
+		if post.Visibility != models.VisibilityPublic {
+			continue
+		}
+		listed = append(listed, post)
+		for _, tag := range post.Tags {
+			tagPosts[tag.ID] = append(tagPosts[tag.ID], post)
+			tagNames[tag.ID] = tag.Name
+		}
+		for _, category := range post.Categories {
+			categoryPosts[category.ID] = append(categoryPosts[category.ID], post)
+			categoryNames[category.ID] = category.Name
+		}
+	}
+
+	// 首页分页
+	if err := renderPaginated(render, "", siteTitle, listed); err != nil {
+		return err
+	}
+
+	// 标签和分类页
+	renderTerms := func(dir, title string, names map[uint]string, termPosts map[uint][]*exportedPost) error {
+		terms := make([]siteTerm, 0, len(names))
+		for id, name := range names {
+			base := dir + "/" + strconv.FormatUint(uint64(id), 10) + "/"
+			terms = append(terms, siteTerm{Name: name, URL: "/" + base, Count: len(termPosts[id])})
+			if err := renderPaginated(render, base, title+"："+name, termPosts[id]); err != nil {
+				return err
+			}
+		}
+		// 按文章数倒序、名称正序排列
+		sort.Slice(terms, func(i, j int) bool {
+			if terms[i].Count != terms[j].Count {
+				return terms[i].Count > terms[j].Count
+			}
+			return terms[i].Name < terms[j].Name
+		})
+		return render(dir+"/index.html", sitePage{Title: title, Terms: terms, IsTermsPage: true})
+	}
+	if err := renderTerms("tags", "标签", tagNames, tagPosts); err != nil {
+		return err
+	}
+	if err := renderTerms("categories", "分类", categoryNames, categoryPosts); err != nil {
+		return err
+	}
+	result.Tags = len(tagNames)
+	result.Categories = len(categoryNames)
+
+	// 复制上传文件，私有对象只能通过签名链接访问，不复制到公开的静态站点
+	if opts.UploadsDir == "" {
+		return nil
+	}
+	if _, err := os.Stat(opts.UploadsDir); os.IsNotExist(err) {
+		return nil
+	}
+	return filepath.WalkDir(opts.UploadsDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(opts.UploadsDir, p)
+		key := filepath.ToSlash(rel)
+		if d.IsDir() {
+			if storage.IsPrivate(key + "/") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if storage.IsPrivate(key) {
+			return nil
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		result.Uploads++
+		return out.WriteFile(path.Join("site/uploads", key), data)
+	})
+}
+
+// 生成分页的文章列表页，第一页为 {base}index.html，之后为 {base}page/{n}/index.html
+func renderPaginated(render func(string, sitePage) error, base, title string, posts []*exportedPost) error {
+	pages := (len(posts) + exportPageSize - 1) / exportPageSize
+	if pages == 0 {
+		pages = 1
+	}
+
+	pageURL := func(n int) string {
+		if n == 1 {
+			return "/" + base
+		}
+		return "/" + base + "page/" + strconv.Itoa(n) + "/"
+	}
+
+	for n := 1; n <= pages; n++ {
+		start := (n - 1) * exportPageSize
+		end := start + exportPageSize
+		if end > len(posts) {
+			end = len(posts)
+		}
+
+		page := sitePage{Title: title, Posts: posts[start:end]}
+		if n > 1 {
+			page.PrevURL = pageURL(n - 1)
+		}
+		if n < pages {
+			page.NextURL = pageURL(n + 1)
+		}
+
+		name := base + "index.html"
+		if n > 1 {
+			name = base + "page/" + strconv.Itoa(n) + "/index.html"
+		}
+		if err := render(name, page); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var siteTemplate = template.Must(template.New("site").Funcs(template.FuncMap{
+	"date": func(t time.Time) string { return t.Format("2006-01-02") },
+	"tagURL": func(id uint) string {
+		return fmt.Sprintf("/tags/%d/", id)
+	},
+	"categoryURL": func(id uint) string {
+		return fmt.Sprintf("/categories/%d/", id)
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{if .IsPostPage}}{{.Title}} - {{.SiteTitle}}{{else if eq .Title .SiteTitle}}{{.SiteTitle}}{{else}}{{.Title}} - {{.SiteTitle}}{{end}}</title>
+<style>
+body{max-width:760px;margin:0 auto;padding:24px 16px;font:16px/1.75 -apple-system,"PingFang SC","Microsoft YaHei",sans-serif;color:#222}
+a{color:#2563eb;text-decoration:none}a:hover{text-decoration:underline}
+header,footer{display:flex;gap:16px;align-items:center;color:#666;font-size:14px}
+header{border-bottom:1px solid #eee;padding-bottom:12px;margin-bottom:24px}
+header .brand{font-size:20px;font-weight:600;color:#222;margin-right:auto}
+footer{border-top:1px solid #eee;padding-top:12px;margin-top:48px}
+.meta{color:#888;font-size:14px}.meta a{margin-right:6px}
+article img,.cover{max-width:100%}
+pre{background:#f6f8fa;padding:12px;overflow:auto;border-radius:4px}
+code{font-family:Menlo,Consolas,monospace;font-size:14px}
+blockquote{margin:0;padding-left:16px;border-left:4px solid #ddd;color:#555}
+table{border-collapse:collapse}th,td{border:1px solid #ddd;padding:4px 8px}
+.pager{display:flex;justify-content:space-between;margin-top:32px}
+</style>
+</head>
+<body>
+<header>
+<a class="brand" href="/">{{.SiteTitle}}</a>
+<a href="/tags/">标签</a>
+<a href="/categories/">分类</a>
+</header>
+{{if .IsPostPage}}{{with .Post}}
+<article>
+<h1>{{.Title}}</h1>
+<p class="meta">{{date .CreatedAt}} · {{.User.Username}}
+{{range .Categories}}<a href="{{categoryURL .ID}}">{{.Name}}</a>{{end}}
+{{range .Tags}}<a href="{{tagURL .ID}}">#{{.Name}}</a>{{end}}</p>
+{{if .Cover}}<img class="cover" src="{{.Cover}}" alt="">{{end}}
+{{.HTML}}
+</article>
+{{end}}{{else if .IsTermsPage}}
+<h1>{{.Title}}</h1>
+<ul>
+{{range .Terms}}<li><a href="{{.URL}}">{{.Name}}</a> <span class="meta">({{.Count}})</span></li>
+{{else}}<li>暂无</li>
+{{end}}</ul>
+{{else}}
+{{if ne .Title .SiteTitle}}<h1>{{.Title}}</h1>{{end}}
+{{range .Posts}}
+<section>
+<h2><a href="/posts/{{.Name}}/">{{.Title}}</a></h2>
+<p class="meta">{{date .CreatedAt}} · {{.User.Username}}</p>
+{{if .Summary}}<p>{{.Summary}}</p>{{end}}
+</section>
+{{else}}<p>暂无文章</p>{{end}}
+<nav class="pager">
+<span>{{if .PrevURL}}<a href="{{.PrevURL}}">« 上一页</a>{{end}}</span>
+<span>{{if .NextURL}}<a href="{{.NextURL}}">下一页 »</a>{{end}}</span>
+</nav>
+{{end}}
+<footer>生成于 {{date .Generated}}</footer>
+</body>
+</html>
+`))
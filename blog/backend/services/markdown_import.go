@@ -0,0 +1,577 @@
+package services
+
+import (
+	"archive/zip"
+	"blog/config"
+	"blog/models"
+	"blog/utils"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+)
+
+// 单个Markdown文件的最大大小
+const MaxMarkdownFileSize = 5 << 20
+
+// MarkdownFile 待导入的Markdown文件
+type MarkdownFile struct {
+	Path    string
+	Content []byte
+}
+
+// FrontMatter Hugo/Jekyll文章头部的YAML元数据
+type FrontMatter struct {
+	Title       string          `yaml:"title"`
+	Date        frontMatterTime `yaml:"date"`
+	LastMod     frontMatterTime `yaml:"lastmod"`
+	Tags        stringList      `yaml:"tags"`
+	Categories  stringList      `yaml:"categories"`
+	Summary     string          `yaml:"summary"`
+	Description string          `yaml:"description"`
+	Excerpt     string          `yaml:"excerpt"`
+	Cover       coverField      `yaml:"cover"`
+	Image       string          `yaml:"image"`
+	Draft       bool            `yaml:"draft"`
+	Published   *bool           `yaml:"published"` // Jekyll使用published: false表示草稿
+	Slug        string          `yaml:"slug"`
+	Lang        string          `yaml:"lang"`
+}
+
+// ParsedMarkdownPost 解析后的文章
+type ParsedMarkdownPost struct {
+	Path       string
+	Slug       string
+	Language   string
+	Title      string
+	Content    string
+	Summary    string
+	Cover      string
+	Status     string
+	Tags       []string
+	Categories []string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// ImportFieldChange 预览中单个字段的变化
+type ImportFieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// ImportItem 单个文件的导入结果
+type ImportItem struct {
+	File    string              `json:"file"`
+	Action  string              `json:"action"` // create, update, unchanged, error
+	Slug    string              `json:"slug,omitempty"`
+	Lang    string              `json:"lang,omitempty"`
+	Title   string              `json:"title,omitempty"`
+	PostID  uint                `json:"postId,omitempty"`
+	Changes []ImportFieldChange `json:"changes,omitempty"`
+	Error   string              `json:"error,omitempty"`
+}
+
+// ImportReport 导入报告，DryRun为true时数据库未做任何修改
+type ImportReport struct {
+	DryRun    bool         `json:"dryRun"`
+	Created   int          `json:"created"`
+	Updated   int          `json:"updated"`
+	Unchanged int          `json:"unchanged"`
+	Failed    int          `json:"failed"`
+	Items     []ImportItem `json:"items"`
+}
+
+// 导入操作类型
+const (
+	ImportActionCreate    = "create"
+	ImportActionUpdate    = "update"
+	ImportActionUnchanged = "unchanged"
+	ImportActionError     = "error"
+)
+
+// Jekyll文件名中的日期前缀，如 2020-01-02-hello-world.md
+var jekyllFileDate = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})-(.+)$`)
+
+// ReadMarkdownDir 递归读取目录下的所有Markdown文件
+func ReadMarkdownDir(dir string) ([]MarkdownFile, error) {
+	var files []MarkdownFile
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !isMarkdownFile(p) {
+			return nil
+		}
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, p)
+		files = append(files, MarkdownFile{Path: filepath.ToSlash(rel), Content: content})
+		return nil
+	})
+	return files, err
+}
+
+// ReadMarkdownZip 读取zip压缩包中的所有Markdown文件
+func ReadMarkdownZip(r io.ReaderAt, size int64) ([]MarkdownFile, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []MarkdownFile
+	for _, entry := range archive.File {
+		if entry.FileInfo().IsDir() || !isMarkdownFile(entry.Name) {
+			continue
+		}
+		if entry.UncompressedSize64 > MaxMarkdownFileSize {
+			return nil, fmt.Errorf("%s: 文件过大", entry.Name)
+		}
+		rc, err := entry.Open()
+		if err != nil {
+			return nil, err
+		}
+		content, err := io.ReadAll(io.LimitReader(rc, MaxMarkdownFileSize+1))
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, MarkdownFile{Path: entry.Name, Content: content})
+	}
+	return files, nil
+}
+
+// ParseMarkdownPost 解析Markdown文件的front matter和正文
+func ParseMarkdownPost(file MarkdownFile) (*ParsedMarkdownPost, error) {
+	if len(file.Content) > MaxMarkdownFileSize {
+		return nil, errors.New("文件过大")
+	}
+	if !utf8.Valid(file.Content) {
+		return nil, errors.New("文件不是有效的UTF-8编码")
+	}
+
+	front, body, err := splitFrontMatter(file.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	var meta FrontMatter
+	if err := yaml.Unmarshal(front, &meta); err != nil {
+		return nil, fmt.Errorf("解析front matter失败: %v", err)
+	}
+
+	post := &ParsedMarkdownPost{
+		Path:       file.Path,
+		Slug:       strings.TrimSpace(meta.Slug),
+		Language:   utils.NormalizeLanguage(meta.Lang),
+		Title:      strings.TrimSpace(meta.Title),
+		Content:    strings.TrimSpace(body),
+		Summary:    firstNonEmpty(meta.Summary, meta.Description, meta.Excerpt),
+		Cover:      firstNonEmpty(string(meta.Cover), meta.Image),
+		Status:     "published",
+		Tags:       []string(meta.Tags),
+		Categories: []string(meta.Categories),
+		CreatedAt:  time.Time(meta.Date),
+		UpdatedAt:  time.Time(meta.LastMod),
+	}
+	if meta.Draft || (meta.Published != nil && !*meta.Published) {
+		post.Status = "draft"
+	}
+
+	// 从文件名推断语言、slug和日期，Hugo 的多语言文件名形如 post.en.md
+	name := strings.TrimSuffix(path.Base(file.Path), path.Ext(file.Path))
+	if lang := utils.NormalizeLanguage(strings.TrimPrefix(path.Ext(name), ".")); models.IsSupportedLanguage(lang) {
+		name = strings.TrimSuffix(name, path.Ext(name))
+		if post.Language == "" {
+			post.Language = lang
+		}
+	}
+	if name == "index" || name == "_index" {
+		name = path.Base(path.Dir(file.Path))
+	}
+	if match := jekyllFileDate.FindStringSubmatch(name); match != nil {
+		name = match[2]
+		if post.CreatedAt.IsZero() {
+			post.CreatedAt, _ = time.ParseInLocation("2006-01-02", match[1], time.Local)
+		}
+	}
+	if post.Slug == "" {
+		post.Slug = name
+	}
+
+	if post.Language == "" {
+		post.Language = models.DefaultLanguage
+	}
+	if !models.IsSupportedLanguage(post.Language) {
+		return nil, errors.New("不支持的语言: " + post.Language)
+	}
+
+	if post.Title == "" {
+		return nil, errors.New("缺少标题（title）")
+	}
+	if post.Content == "" {
+		return nil, errors.New("正文为空")
+	}
+	if utf8.RuneCountInString(post.Title) > 200 {
+		return nil, errors.New("标题超过200个字符")
+	}
+	if utf8.RuneCountInString(post.Summary) > 500 {
+		post.Summary = string([]rune(post.Summary)[:500])
+	}
+	if post.CreatedAt.IsZero() {
+		post.CreatedAt = time.Now()
+	}
+	if post.UpdatedAt.IsZero() || post.UpdatedAt.Before(post.CreatedAt) {
+		post.UpdatedAt = post.CreatedAt
+	}
+
+	return post, nil
+}
+
+// ImportMarkdown 导入Markdown文件，按语言和slug匹配已有文章：不存在时创建，存在时更新
+// commit为false时只生成预览报告，不修改数据库；文件解析失败只影响该文件，数据库错误会回滚全部导入
+func ImportMarkdown(files []MarkdownFile, authorID uint, commit bool) (*ImportReport, error) {
+	report := &ImportReport{DryRun: !commit, Items: make([]ImportItem, 0, len(files))}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+
+	var updated []uint
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		seen := make(map[string]string, len(files))
+		for _, file := range files {
+			item := ImportItem{File: file.Path}
+
+			parsed, err := ParseMarkdownPost(file)
+			if err != nil {
+				item.Action = ImportActionError
+				item.Error = err.Error()
+				report.add(item)
+				continue
+			}
+			item.Slug = parsed.Slug
+			item.Lang = parsed.Language
+			item.Title = parsed.Title
+
+			key := parsed.Language + "/" + parsed.Slug
+			if other, exists := seen[key]; exists {
+				item.Action = ImportActionError
+				item.Error = "slug与 " + other + " 重复"
+				report.add(item)
+				continue
+			}
+			seen[key] = file.Path
+
+			var existing models.Post
+			found := tx.Preload("Tags").Preload("Categories").
+				Where("slug = ? AND language = ?", parsed.Slug, parsed.Language).
+				First(&existing).Error == nil
+
+			if !found {
+				item.Action = ImportActionCreate
+				if commit {
+					postID, err := createImportedPost(tx, parsed, authorID)
+					if err != nil {
+						return fmt.Errorf("%s: %v", file.Path, err)
+					}
+					item.PostID = postID
+				}
+				report.add(item)
+				continue
+			}
+
+			item.PostID = existing.ID
+			item.Changes = diffImportedPost(&existing, parsed)
+			if len(item.Changes) == 0 {
+				item.Action = ImportActionUnchanged
+				report.add(item)
+				continue
+			}
+
+			item.Action = ImportActionUpdate
+			if commit {
+				if err := updateImportedPost(tx, &existing, parsed); err != nil {
+					return fmt.Errorf("%s: %v", file.Path, err)
+				}
+				updated = append(updated, existing.ID)
+			}
+			report.add(item)
+		}
+
+		if !commit {
+			// 预览模式下回滚事务，确保不留下任何修改
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && err != errDryRun {
+		return nil, err
+	}
+
+	// 事务提交后清理被更新文章的详情缓存
+	if commit && len(updated) > 0 {
+		keys := make([]string, 0, len(updated))
+		for _, id := range updated {
+			keys = append(keys, PostCacheKey(id))
+		}
+		config.Redis.Del(context.Background(), keys...)
+	}
+
+	return report, nil
+}
+
+// 用于在预览模式下回滚事务
+var errDryRun = errors.New("dry run")
+
+func (r *ImportReport) add(item ImportItem) {
+	switch item.Action {
+	case ImportActionCreate:
+		r.Created++
+	case ImportActionUpdate:
+		r.Updated++
+	case ImportActionUnchanged:
+		r.Unchanged++
+	case ImportActionError:
+		r.Failed++
+	}
+	r.Items = append(r.Items, item)
+}
+
+// 创建导入的文章，保留原始的创建和更新时间
+func createImportedPost(tx *gorm.DB, parsed *ParsedMarkdownPost, authorID uint) (uint, error) {
+	post := models.Post{
+		Title:      parsed.Title,
+		Slug:       parsed.Slug,
+		Language:   parsed.Language,
+		Content:    parsed.Content,
+		Summary:    parsed.Summary,
+		Cover:      parsed.Cover,
+		Status:     parsed.Status,
+		Visibility: models.VisibilityPublic,
+		UserID:     authorID,
+		CreatedAt:  parsed.CreatedAt,
+		UpdatedAt:  parsed.UpdatedAt,
+	}
+	post.ComputeContentStats()
+	if err := tx.Create(&post).Error; err != nil {
+		return 0, err
+	}
+	if err := setImportedTaxonomy(tx, &post, parsed.Tags, parsed.Categories, parsed.UpdatedAt); err != nil {
+		return 0, err
+	}
+	return post.ID, nil
+}
+
+// 用导入的内容覆盖已有文章
+func updateImportedPost(tx *gorm.DB, post *models.Post, parsed *ParsedMarkdownPost) error {
+	columns := models.ContentStatsColumns(parsed.Content)
+	for column, value := range map[string]interface{}{
+		"title":      parsed.Title,
+		"content":    parsed.Content,
+		"summary":    parsed.Summary,
+		"cover":      parsed.Cover,
+		"status":     parsed.Status,
+		"created_at": parsed.CreatedAt,
+		"updated_at": parsed.UpdatedAt,
+	} {
+		columns[column] = value
+	}
+
+	// 使用UpdateColumns避免updated_at被改为当前时间
+	err := tx.Model(post).UpdateColumns(columns).Error
+	if err != nil {
+		return err
+	}
+	return setImportedTaxonomy(tx, post, parsed.Tags, parsed.Categories, parsed.UpdatedAt)
+}
+
+// 设置文章的标签和分类（不存在时自动创建）
+func setImportedTaxonomy(tx *gorm.DB, post *models.Post, tagNames, categoryNames []string, updatedAt time.Time) error {
+	tags, err := FindOrCreateTags(tx, tagNames)
+	if err != nil {
+		return err
+	}
+	if err := tx.Model(post).Association("Tags").Replace(tags); err != nil {
+		return err
+	}
+
+	categories, err := FindOrCreateCategories(tx, categoryNames)
+	if err != nil {
+		return err
+	}
+	if err := tx.Model(post).Association("Categories").Replace(categories); err != nil {
+		return err
+	}
+
+	// 更新关联时GORM会把updated_at改为当前时间，这里恢复为原始时间
+	return tx.Model(post).UpdateColumn("updated_at", updatedAt).Error
+}
+
+// 比较已有文章与导入内容的差异
+func diffImportedPost(post *models.Post, parsed *ParsedMarkdownPost) []ImportFieldChange {
+	var changes []ImportFieldChange
+	compare := func(field, old, new string) {
+		if old != new {
+			changes = append(changes, ImportFieldChange{Field: field, Old: old, New: new})
+		}
+	}
+
+	compare("title", post.Title, parsed.Title)
+	compare("summary", post.Summary, parsed.Summary)
+	compare("cover", post.Cover, parsed.Cover)
+	compare("status", post.Status, parsed.Status)
+	if post.Content != parsed.Content {
+		// 正文只报告长度变化，避免报告过大
+		changes = append(changes, ImportFieldChange{
+			Field: "content",
+			Old:   fmt.Sprintf("%d 字符", utf8.RuneCountInString(post.Content)),
+			New:   fmt.Sprintf("%d 字符", utf8.RuneCountInString(parsed.Content)),
+		})
+	}
+	if !post.CreatedAt.Equal(parsed.CreatedAt) {
+		compare("createdAt", post.CreatedAt.Format(time.RFC3339), parsed.CreatedAt.Format(time.RFC3339))
+	}
+
+	tagNames := make([]string, 0, len(post.Tags))
+	for _, tag := range post.Tags {
+		tagNames = append(tagNames, tag.Name)
+	}
+	compare("tags", joinSorted(tagNames), joinSorted(uniqueNames(parsed.Tags)))
+
+	categoryNames := make([]string, 0, len(post.Categories))
+	for _, category := range post.Categories {
+		categoryNames = append(categoryNames, category.Name)
+	}
+	compare("categories", joinSorted(categoryNames), joinSorted(uniqueNames(parsed.Categories)))
+
+	return changes
+}
+
+// 拆分front matter和正文，只支持以---分隔的YAML格式
+func splitFrontMatter(content []byte) ([]byte, string, error) {
+	content = bytes.TrimPrefix(content, []byte("\xef\xbb\xbf"))
+	lines := strings.Split(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n")
+
+	if strings.TrimSpace(lines[0]) == "+++" {
+		return nil, "", errors.New("暂不支持TOML格式的front matter，请转换为YAML")
+	}
+	if strings.TrimSpace(lines[0]) != "---" {
+		return nil, "", errors.New("缺少YAML front matter")
+	}
+
+	for i := 1; i < len(lines); i++ {
+		if line := strings.TrimSpace(lines[i]); line == "---" || line == "..." {
+			front := strings.Join(lines[1:i], "\n")
+			body := strings.Join(lines[i+1:], "\n")
+			return []byte(front), body, nil
+		}
+	}
+	return nil, "", errors.New("front matter未结束")
+}
+
+func isMarkdownFile(name string) bool {
+	ext := strings.ToLower(path.Ext(name))
+	return ext == ".md" || ext == ".markdown"
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func joinSorted(values []string) string {
+	sorted := append([]string{}, values...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ", ")
+}
+
+// 支持的日期格式
+var frontMatterTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05 -07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// front matter中的日期，未指定时区时按本地时间解析
+type frontMatterTime time.Time
+
+func (t *frontMatterTime) UnmarshalYAML(node *yaml.Node) error {
+	value := strings.TrimSpace(node.Value)
+	if value == "" {
+		return nil
+	}
+	for _, layout := range frontMatterTimeLayouts {
+		if parsed, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			*t = frontMatterTime(parsed)
+			return nil
+		}
+	}
+	return fmt.Errorf("无法解析日期: %s", value)
+}
+
+// 标签和分类既可以是列表，也可以是逗号或空格分隔的字符串（Jekyll）
+type stringList []string
+
+func (l *stringList) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.SequenceNode:
+		var values []string
+		if err := node.Decode(&values); err != nil {
+			return err
+		}
+		*l = values
+	case yaml.ScalarNode:
+		if strings.Contains(node.Value, ",") {
+			*l = strings.Split(node.Value, ",")
+		} else {
+			*l = strings.Fields(node.Value)
+		}
+	default:
+		return fmt.Errorf("第%d行: 应为列表或字符串", node.Line)
+	}
+	return nil
+}
+
+// 封面图既可以是字符串，也可以是 {image: ...} 形式（Hugo主题常用）
+type coverField string
+
+func (c *coverField) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		*c = coverField(node.Value)
+	case yaml.MappingNode:
+		var value struct {
+			Image string `yaml:"image"`
+		}
+		if err := node.Decode(&value); err != nil {
+			return err
+		}
+		*c = coverField(value.Image)
+	default:
+		return fmt.Errorf("第%d行: 无法解析封面", node.Line)
+	}
+	return nil
+}
@@ -0,0 +1,255 @@
+package services
+
+import (
+	"blog/config"
+	"blog/models"
+	"blog/utils"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"log"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// 单个媒体文件的最大大小
+	MaxMediaSize = 10 << 20
+	// 图片的最大像素数，防止解码超大图片耗尽内存
+	maxImagePixels = 40_000_000
+	// 重新编码JPEG时的质量
+	jpegQuality = 85
+	// 媒体文件在存储中的目录
+	mediaDir = "media"
+	// 头像的最大大小和边长
+	MaxAvatarSize = 2 << 20
+	avatarMaxSide = 256
+	AvatarDir     = "avatars"
+)
+
+// 衍生尺寸的最大边长
+var mediaVariantSizes = []struct {
+	Name string
+	Max  int
+}{
+	{models.MediaVariantThumb, 320},
+	{models.MediaVariantMedium, 800},
+	{models.MediaVariantLarge, 1600},
+}
+
+var (
+	ErrMediaTooLarge        = errors.New("文件大小超过限制")
+	ErrUnsupportedMediaType = errors.New("不支持的文件类型，仅支持JPEG、PNG、GIF和WebP图片")
+	ErrInvalidImage         = errors.New("无法解析图片")
+)
+
+// ProcessedImage 校验并去除元数据后的图片
+type ProcessedImage struct {
+	Data     []byte
+	MimeType string
+	Ext      string
+	Width    int
+	Height   int
+	image    image.Image // 已解码的图片，用于生成衍生尺寸；GIF和WebP为nil
+}
+
+// ProcessImage 按内容识别图片类型，检查大小和尺寸，并去除EXIF等元数据
+// JPEG和PNG会按EXIF方向纠正后重新编码；GIF保留原文件以保留动画；WebP只移除EXIF和XMP数据块
+func ProcessImage(data []byte) (*ProcessedImage, error) {
+	if len(data) > MaxMediaSize {
+		return nil, ErrMediaTooLarge
+	}
+
+	mimeType, ext, ok := utils.DetectImageType(data)
+	if !ok {
+		return nil, ErrUnsupportedMediaType
+	}
+	result := &ProcessedImage{MimeType: mimeType, Ext: ext}
+
+	if mimeType == "image/webp" {
+		width, height, ok := utils.WebPSize(data)
+		if !ok || width*height > maxImagePixels {
+			return nil, ErrInvalidImage
+		}
+		result.Data, result.Width, result.Height = utils.StripWebPMetadata(data), width, height
+		return result, nil
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || cfg.Width*cfg.Height > maxImagePixels {
+		return nil, ErrInvalidImage
+	}
+
+	if mimeType == "image/gif" {
+		result.Data, result.Width, result.Height = data, cfg.Width, cfg.Height
+		return result, nil
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	if mimeType == "image/jpeg" {
+		img = utils.ApplyOrientation(img, utils.JPEGOrientation(data))
+	}
+
+	if result.Data, err = encodeImage(img, mimeType); err != nil {
+		return nil, err
+	}
+	result.image = img
+	result.Width, result.Height = img.Bounds().Dx(), img.Bounds().Dy()
+	return result, nil
+}
+
+// ResizedCopy 生成不超过指定边长的缩小副本，图片本身更小或无法缩放（GIF、WebP）时返回nil
+func (p *ProcessedImage) ResizedCopy(max int) (*ProcessedImage, error) {
+	if p.image == nil || (p.Width <= max && p.Height <= max) {
+		return nil, nil
+	}
+
+	resized := utils.ResizeToFit(p.image, max, max)
+	data, err := encodeImage(resized, p.MimeType)
+	if err != nil {
+		return nil, err
+	}
+	return &ProcessedImage{
+		Data:     data,
+		MimeType: p.MimeType,
+		Ext:      p.Ext,
+		Width:    resized.Bounds().Dx(),
+		Height:   resized.Bounds().Dy(),
+		image:    resized,
+	}, nil
+}
+
+func encodeImage(img image.Image, mimeType string) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	switch mimeType {
+	case "image/jpeg":
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality})
+	case "image/png":
+		err = png.Encode(&buf, img)
+	case "image/gif":
+		err = gif.Encode(&buf, img, nil)
+	default:
+		return nil, ErrUnsupportedMediaType
+	}
+	return buf.Bytes(), err
+}
+
+// SaveMedia 处理并保存上传的图片，生成衍生尺寸，创建媒体记录
+func SaveMedia(userID uint, fileName, alt string, data []byte) (*models.Media, error) {
+	processed, err := ProcessImage(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// 按年月分目录存放
+	dir := path.Join(mediaDir, time.Now().Format("2006/01"))
+	name := uuid.NewString()
+
+	media := &models.Media{
+		UserID:   userID,
+		FileName: filepath.Base(fileName),
+		Alt:      alt,
+		MimeType: processed.MimeType,
+		Size:     int64(len(processed.Data)),
+		Width:    processed.Width,
+		Height:   processed.Height,
+		Path:     path.Join(dir, name+processed.Ext),
+		Variants: map[string]models.MediaVariant{},
+	}
+	media.URL = config.Storage.URL(media.Path)
+
+	ctx := context.Background()
+	var written []string
+	cleanup := func() {
+		for _, key := range written {
+			config.Storage.Delete(ctx, key)
+		}
+	}
+
+	if err := config.Storage.Put(ctx, media.Path, processed.Data, processed.MimeType); err != nil {
+		return nil, err
+	}
+	written = append(written, media.Path)
+
+	for _, size := range mediaVariantSizes {
+		resized, err := processed.ResizedCopy(size.Max)
+		if err != nil {
+			cleanup()
+			return nil, fmt.Errorf("生成缩略图失败: %v", err)
+		}
+		if resized == nil {
+			continue
+		}
+
+		variantPath := path.Join(dir, name+"_"+size.Name+resized.Ext)
+		if err := config.Storage.Put(ctx, variantPath, resized.Data, resized.MimeType); err != nil {
+			cleanup()
+			return nil, err
+		}
+		written = append(written, variantPath)
+
+		media.Variants[size.Name] = models.MediaVariant{
+			Path:   variantPath,
+			URL:    config.Storage.URL(variantPath),
+			Width:  resized.Width,
+			Height: resized.Height,
+		}
+	}
+
+	if err := config.DB.Create(media).Error; err != nil {
+		cleanup()
+		return nil, err
+	}
+	return media, nil
+}
+
+// SaveAvatar 校验并保存头像，去除元数据并缩小到固定尺寸，返回访问URL
+func SaveAvatar(data []byte) (string, error) {
+	if len(data) > MaxAvatarSize {
+		return "", ErrMediaTooLarge
+	}
+	processed, err := ProcessImage(data)
+	if err != nil {
+		return "", err
+	}
+
+	resized, err := processed.ResizedCopy(avatarMaxSide)
+	if err != nil {
+		return "", err
+	}
+	if resized != nil {
+		processed = resized
+	}
+
+	key := path.Join(AvatarDir, uuid.NewString()+processed.Ext)
+	if err := config.Storage.Put(context.Background(), key, processed.Data, processed.MimeType); err != nil {
+		return "", err
+	}
+	return config.Storage.URL(key), nil
+}
+
+// DeleteMediaFiles 删除媒体文件及其所有衍生尺寸
+func DeleteMediaFiles(media *models.Media) {
+	ctx := context.Background()
+	keys := []string{media.Path}
+	for _, variant := range media.Variants {
+		keys = append(keys, variant.Path)
+	}
+	for _, key := range keys {
+		if err := config.Storage.Delete(ctx, key); err != nil {
+			log.Printf("删除媒体文件 %s 失败: %v", key, err)
+		}
+	}
+}
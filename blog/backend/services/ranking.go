@@ -0,0 +1,251 @@
+package services
+
+import (
+	"blog/config"
+	"blog/models"
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// 热门和趋势排行重新计算的间隔
+	RankingInterval = 10 * time.Minute
+	// 排行及其计算标记的有效期，后台任务停止后过期的排行不再使用
+	rankingTTL = 3 * RankingInterval
+
+	// 互动的权重：浏览、点赞、评论、收藏
+	rankingViewWeight     = 1.0
+	rankingLikeWeight     = 4.0
+	rankingCommentWeight  = 6.0
+	rankingFavoriteWeight = 8.0
+
+	// 趋势分数的时间衰减指数（Hacker News算法中的gravity）
+	rankingGravity = 1.8
+	// 每个排行保留的文章数量
+	rankingLimit = 500
+)
+
+// 排行的统计窗口
+const (
+	RankingWindowDay   = "day"
+	RankingWindowWeek  = "week"
+	RankingWindowMonth = "month"
+	RankingWindowAll   = "all"
+)
+
+// 排行类型
+const (
+	RankingTrending = "trending" // 按发布时间衰减后的分数排序
+	RankingPopular  = "popular"  // 按窗口内的互动总分排序
+)
+
+// RankingWindows 支持的统计窗口及时长，all为0表示不限时间
+var RankingWindows = map[string]time.Duration{
+	RankingWindowDay:   24 * time.Hour,
+	RankingWindowWeek:  7 * 24 * time.Hour,
+	RankingWindowMonth: 30 * 24 * time.Hour,
+	RankingWindowAll:   0,
+}
+
+// RankingKey 返回排行在Redis中的键（有序集合，成员为文章ID，分数越高越靠前）
+func RankingKey(kind, window string) string {
+	return fmt.Sprintf("posts:%s:%s", kind, window)
+}
+
+// 记录某个窗口排行计算时间的键，存在即表示已计算过（排行为空时排行键本身不存在）
+func rankingComputedKey(window string) string {
+	return fmt.Sprintf("posts:ranking:computed:%s", window)
+}
+
+// 防止多个请求同时触发同一窗口计算的锁
+func rankingLockKey(window string) string {
+	return fmt.Sprintf("posts:ranking:lock:%s", window)
+}
+
+// StartRankingWorker 启动后台任务，定期重新计算所有窗口的热门和趋势排行
+func StartRankingWorker() {
+	go func() {
+		for {
+			for window := range RankingWindows {
+				if err := ComputeRankings(window); err != nil {
+					log.Printf("计算文章排行失败(%s): %v", window, err)
+				}
+			}
+			time.Sleep(RankingInterval)
+		}
+	}()
+}
+
+// ComputeRankings 统计窗口内每篇文章的浏览、点赞、评论和收藏，计算热门和趋势分数并写入Redis
+// 趋势分数 = 互动分 / (发布后小时数 + 2) ^ gravity，新文章在互动相同时排名更靠前
+func ComputeRankings(window string) error {
+	duration, ok := RankingWindows[window]
+	if !ok {
+		return fmt.Errorf("不支持的统计窗口: %s", window)
+	}
+
+	now := time.Now()
+	var since time.Time
+	if duration > 0 {
+		since = now.Add(-duration)
+	}
+
+	var posts []models.Post
+	if err := config.DB.Select("id, created_at, view_count").
+		Where("status = ? AND visibility IN ?", "published", []string{models.VisibilityPublic, models.VisibilityPassword}).
+		Find(&posts).Error; err != nil {
+		return err
+	}
+
+	type postCount struct {
+		PostID uint
+		Total  float64
+	}
+	collect := func(query string, args ...interface{}) (map[uint]float64, error) {
+		var rows []postCount
+		if err := config.DB.Raw(query, args...).Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+		counts := make(map[uint]float64, len(rows))
+		for _, row := range rows {
+			counts[row.PostID] = row.Total
+		}
+		return counts, nil
+	}
+
+	var views map[uint]float64
+	var err error
+	if duration > 0 {
+		views, err = collect("SELECT post_id, SUM(views) AS total FROM post_view_dailies WHERE date >= ? GROUP BY post_id", since.Format("2006-01-02"))
+	} else {
+		views = make(map[uint]float64, len(posts))
+		for _, post := range posts {
+			views[post.ID] = float64(post.ViewCount)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	likes, err := collect("SELECT post_id, COUNT(*) AS total FROM reactions WHERE type = ? AND created_at >= ? GROUP BY post_id", models.ReactionTypeLike, since)
+	if err != nil {
+		return err
+	}
+	comments, err := collect("SELECT post_id, COUNT(*) AS total FROM comments WHERE deleted_at IS NULL AND created_at >= ? GROUP BY post_id", since)
+	if err != nil {
+		return err
+	}
+	favorites, err := collect("SELECT post_id, COUNT(*) AS total FROM favorites WHERE deleted_at IS NULL AND created_at >= ? GROUP BY post_id", since)
+	if err != nil {
+		return err
+	}
+
+	var popular, trending []redis.Z
+	for _, post := range posts {
+		points := views[post.ID]*rankingViewWeight + likes[post.ID]*rankingLikeWeight +
+			comments[post.ID]*rankingCommentWeight + favorites[post.ID]*rankingFavoriteWeight
+		if points <= 0 {
+			continue
+		}
+		ageHours := math.Max(now.Sub(post.CreatedAt).Hours(), 0)
+		member := strconv.FormatUint(uint64(post.ID), 10)
+		popular = append(popular, redis.Z{Score: points, Member: member})
+		trending = append(trending, redis.Z{Score: points / math.Pow(ageHours+2, rankingGravity), Member: member})
+	}
+
+	ctx := context.Background()
+	if err := replaceRanking(ctx, RankingKey(RankingPopular, window), popular); err != nil {
+		return err
+	}
+	if err := replaceRanking(ctx, RankingKey(RankingTrending, window), trending); err != nil {
+		return err
+	}
+	return config.Redis.Set(ctx, rankingComputedKey(window), now.Format(time.RFC3339), rankingTTL).Err()
+}
+
+// 写入临时键后重命名，读取方不会看到写了一半的排行；只保留前 rankingLimit 名
+// 排行为空时删除排行键，是否已计算由 rankingComputedKey 区分
+func replaceRanking(ctx context.Context, key string, members []redis.Z) error {
+	if len(members) == 0 {
+		return config.Redis.Del(ctx, key).Err()
+	}
+
+	tmpKey := key + ":tmp"
+	pipe := config.Redis.TxPipeline()
+	pipe.Del(ctx, tmpKey)
+	pipe.ZAdd(ctx, tmpKey, members...)
+	pipe.ZRemRangeByRank(ctx, tmpKey, 0, int64(-rankingLimit-1))
+	pipe.Rename(ctx, tmpKey, key)
+	pipe.Expire(ctx, key, rankingTTL)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// 在后台计算某个窗口的排行，同一窗口同时只会有一个计算任务
+func computeRankingsAsync(ctx context.Context, window string) {
+	acquired, err := config.Redis.SetNX(ctx, rankingLockKey(window), 1, RankingInterval).Result()
+	if err != nil || !acquired {
+		return
+	}
+	go func() {
+		defer config.Redis.Del(context.Background(), rankingLockKey(window))
+		if err := ComputeRankings(window); err != nil {
+			log.Printf("计算文章排行失败(%s): %v", window, err)
+		}
+	}()
+}
+
+// RankedPost 排行中的一篇文章及其分数
+type RankedPost struct {
+	PostID uint
+	Score  float64
+}
+
+// RankingPage 排行中的一页文章
+type RankingPage struct {
+	Posts      []RankedPost
+	Total      int64      // 排行中的文章总数
+	ComputedAt *time.Time // 排行的计算时间，为nil表示尚未计算
+}
+
+// GetRankedPosts 按排名返回一页文章ID和分数，不在请求中计算排行：
+// 尚未计算或已过期时在后台触发一次计算，本次返回空结果
+func GetRankedPosts(kind, window string, offset, limit int) (*RankingPage, error) {
+	ctx := context.Background()
+	key := RankingKey(kind, window)
+	page := &RankingPage{Posts: []RankedPost{}}
+
+	computedAt, err := config.Redis.Get(ctx, rankingComputedKey(window)).Result()
+	if err == redis.Nil {
+		computeRankingsAsync(ctx, window)
+		return page, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if t, err := time.Parse(time.RFC3339, computedAt); err == nil {
+		page.ComputedAt = &t
+	}
+
+	if page.Total, err = config.Redis.ZCard(ctx, key).Result(); err != nil {
+		return nil, err
+	}
+	members, err := config.Redis.ZRevRangeWithScores(ctx, key, int64(offset), int64(offset+limit-1)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, member := range members {
+		id, err := strconv.ParseUint(fmt.Sprint(member.Member), 10, 64)
+		if err != nil {
+			continue
+		}
+		page.Posts = append(page.Posts, RankedPost{PostID: uint(id), Score: member.Score})
+	}
+	return page, nil
+}
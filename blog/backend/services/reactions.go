@@ -0,0 +1,363 @@
+package services
+
+import (
+	"blog/config"
+	"blog/models"
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// 表态计数对账及点赞通知合并发送的间隔
+	ReactionSyncInterval = 5 * time.Minute
+
+	// 计数有变动、等待对账的文章集合
+	reactionDirtyKey = "post_reactions:dirty"
+	// 有新点赞、等待发送通知的文章集合
+	likeNotifyPendingKey = "like_notify:pending"
+)
+
+// ReactionCountsKey 返回文章表态计数在Redis中的缓存键（Hash，字段为表态类型）
+func ReactionCountsKey(postID uint) string {
+	return fmt.Sprintf("post_reactions:%d", postID)
+}
+
+// 文章待通知的点赞用户集合
+func likeNotifyKey(postID uint) string {
+	return fmt.Sprintf("like_notify:%d", postID)
+}
+
+// AddReaction 添加表态，已存在时不做任何修改，返回是否新增
+func AddReaction(userID, postID uint, reactionType string) (bool, error) {
+	// 先确保Redis中有计数，再写入记录，避免加载时已包含本次表态导致重复计数
+	ctx := context.Background()
+	ensureReactionCounts(ctx, postID)
+
+	reaction := models.Reaction{UserID: userID, PostID: postID, Type: reactionType}
+	result := config.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&reaction)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+
+	config.Redis.HIncrBy(ctx, ReactionCountsKey(postID), reactionType, 1)
+	config.Redis.SAdd(ctx, reactionDirtyKey, postID)
+
+	// 点赞通知不立即发送，由后台任务合并后统一发送
+	if reactionType == models.ReactionTypeLike {
+		config.Redis.SAdd(ctx, likeNotifyKey(postID), userID)
+		config.Redis.SAdd(ctx, likeNotifyPendingKey, postID)
+	}
+
+	return true, nil
+}
+
+// RemoveReaction 取消表态，不存在时不做任何修改，返回是否删除
+func RemoveReaction(userID, postID uint, reactionType string) (bool, error) {
+	ctx := context.Background()
+	ensureReactionCounts(ctx, postID)
+
+	result := config.DB.Where("user_id = ? AND post_id = ? AND type = ?", userID, postID, reactionType).
+		Delete(&models.Reaction{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+
+	config.Redis.HIncrBy(ctx, ReactionCountsKey(postID), reactionType, -1)
+	config.Redis.SAdd(ctx, reactionDirtyKey, postID)
+
+	if reactionType == models.ReactionTypeLike {
+		config.Redis.SRem(ctx, likeNotifyKey(postID), userID)
+	}
+
+	return true, nil
+}
+
+// GetReactionCounts 批量获取文章的表态计数，优先读取Redis，缺失时从数据库加载并回填缓存
+func GetReactionCounts(postIDs []uint) map[uint]map[string]int64 {
+	ctx := context.Background()
+	counts := make(map[uint]map[string]int64, len(postIDs))
+	if len(postIDs) == 0 {
+		return counts
+	}
+
+	pipe := config.Redis.Pipeline()
+	cmds := make([]*redis.MapStringStringCmd, len(postIDs))
+	for i, id := range postIDs {
+		cmds[i] = pipe.HGetAll(ctx, ReactionCountsKey(id))
+	}
+	pipe.Exec(ctx)
+
+	var missing []uint
+	for i, id := range postIDs {
+		values, err := cmds[i].Result()
+		if err != nil || len(values) == 0 {
+			missing = append(missing, id)
+			continue
+		}
+		counts[id] = parseReactionCounts(values)
+	}
+
+	for id, postCounts := range loadReactionCounts(missing) {
+		counts[id] = postCounts
+	}
+
+	return counts
+}
+
+// GetUserReactions 批量获取用户对文章的表态类型
+func GetUserReactions(userID uint, postIDs []uint) map[uint][]string {
+	result := make(map[uint][]string)
+	if userID == 0 || len(postIDs) == 0 {
+		return result
+	}
+
+	var reactions []models.Reaction
+	config.DB.Where("user_id = ? AND post_id IN ?", userID, postIDs).Order("id ASC").Find(&reactions)
+	for _, reaction := range reactions {
+		result[reaction.PostID] = append(result[reaction.PostID], reaction.Type)
+	}
+	return result
+}
+
+// StartReactionWorker 启动后台任务：定期把Redis计数与数据库对账，并合并发送点赞通知
+func StartReactionWorker() {
+	go func() {
+		ticker := time.NewTicker(ReactionSyncInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := ReconcileReactionCounts(); err != nil {
+				log.Printf("表态计数对账失败: %v", err)
+			}
+			if err := FlushLikeNotifications(); err != nil {
+				log.Printf("发送点赞通知失败: %v", err)
+			}
+		}
+	}()
+}
+
+// ReconcileReactionCounts 以表态记录为准重新统计有变动文章的计数，写入数据库并修正Redis
+func ReconcileReactionCounts() error {
+	ctx := context.Background()
+	for {
+		members, err := config.Redis.SPopN(ctx, reactionDirtyKey, 100).Result()
+		if err != nil {
+			return err
+		}
+		if len(members) == 0 {
+			return nil
+		}
+
+		postIDs := make([]uint, 0, len(members))
+		for _, member := range members {
+			if id, err := strconv.ParseUint(member, 10, 64); err == nil {
+				postIDs = append(postIDs, uint(id))
+			}
+		}
+
+		counts := countReactions(postIDs)
+		err = config.DB.Transaction(func(tx *gorm.DB) error {
+			for _, postID := range postIDs {
+				if err := tx.Where("post_id = ?", postID).Delete(&models.PostReactionCount{}).Error; err != nil {
+					return err
+				}
+				for reactionType, count := range counts[postID] {
+					row := models.PostReactionCount{PostID: postID, Type: reactionType, Count: count}
+					if err := tx.Create(&row).Error; err != nil {
+						return err
+					}
+				}
+				if err := tx.Model(&models.Post{}).Where("id = ?", postID).
+					UpdateColumn("like_count", counts[postID][models.ReactionTypeLike]).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			// 对账失败时放回集合，下次重试
+			for _, postID := range postIDs {
+				config.Redis.SAdd(ctx, reactionDirtyKey, postID)
+			}
+			return err
+		}
+
+		for _, postID := range postIDs {
+			cacheReactionCounts(ctx, postID, counts[postID])
+		}
+	}
+}
+
+// FlushLikeNotifications 把一段时间内的点赞合并为一条通知发送给文章作者
+func FlushLikeNotifications() error {
+	ctx := context.Background()
+	for {
+		member, err := config.Redis.SPop(ctx, likeNotifyPendingKey).Result()
+		if err == redis.Nil {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		id, err := strconv.ParseUint(member, 10, 64)
+		if err != nil {
+			continue
+		}
+		postID := uint(id)
+
+		// 取出并清空待通知的点赞用户
+		pipe := config.Redis.TxPipeline()
+		membersCmd := pipe.SMembers(ctx, likeNotifyKey(postID))
+		pipe.Del(ctx, likeNotifyKey(postID))
+		if _, err := pipe.Exec(ctx); err != nil {
+			return err
+		}
+
+		var likerIDs []uint
+		for _, m := range membersCmd.Val() {
+			if likerID, err := strconv.ParseUint(m, 10, 64); err == nil {
+				likerIDs = append(likerIDs, uint(likerID))
+			}
+		}
+
+		if err := sendLikeNotification(postID, likerIDs); err != nil {
+			log.Printf("发送文章%d的点赞通知失败: %v", postID, err)
+		}
+	}
+}
+
+// 发送合并后的点赞通知
+func sendLikeNotification(postID uint, likerIDs []uint) error {
+	var post models.Post
+	if err := config.DB.Select("id, title, user_id").First(&post, postID).Error; err != nil {
+		return err
+	}
+
+	// 排除作者本人
+	var users []models.User
+	config.DB.Select("id, username").
+		Where("id IN ? AND id <> ?", likerIDs, post.UserID).
+		Order("id ASC").
+		Find(&users)
+	if len(users) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, 2)
+	for i := 0; i < len(users) && i < 2; i++ {
+		names = append(names, users[i].Username)
+	}
+
+	var content string
+	if len(users) > 2 {
+		content = fmt.Sprintf("%s 等%d人赞了你的文章《%s》", strings.Join(names, "、"), len(users), post.Title)
+	} else {
+		content = fmt.Sprintf("%s 赞了你的文章《%s》", strings.Join(names, "、"), post.Title)
+	}
+
+	// 单人点赞时记录发送者，多人时只记录第一位
+	senderID := users[0].ID
+	notification := models.Notification{
+		Type:        models.NotificationTypeLike,
+		Content:     content,
+		UserID:      post.UserID,
+		SenderID:    &senderID,
+		PostID:      &post.ID,
+		RedirectURL: fmt.Sprintf("/posts/%d", post.ID),
+		IsRead:      false,
+	}
+
+	return config.DB.Create(&notification).Error
+}
+
+// Redis中没有计数时，从数据库加载一次，避免在空Hash上增减导致计数偏差
+func ensureReactionCounts(ctx context.Context, postID uint) {
+	exists, err := config.Redis.Exists(ctx, ReactionCountsKey(postID)).Result()
+	if err == nil && exists > 0 {
+		return
+	}
+	loadReactionCounts([]uint{postID})
+}
+
+// 从数据库加载表态计数并写入Redis
+func loadReactionCounts(postIDs []uint) map[uint]map[string]int64 {
+	result := make(map[uint]map[string]int64, len(postIDs))
+	if len(postIDs) == 0 {
+		return result
+	}
+
+	// 以实时统计为准，避免对账前的数据不一致
+	counts := countReactions(postIDs)
+	ctx := context.Background()
+	for _, postID := range postIDs {
+		result[postID] = counts[postID]
+		cacheReactionCounts(ctx, postID, counts[postID])
+	}
+	return result
+}
+
+// 按文章和类型统计表态数量
+func countReactions(postIDs []uint) map[uint]map[string]int64 {
+	var rows []struct {
+		PostID uint
+		Type   string
+		Count  int64
+	}
+	config.DB.Model(&models.Reaction{}).
+		Select("post_id, type, COUNT(*) AS count").
+		Where("post_id IN ?", postIDs).
+		Group("post_id, type").
+		Scan(&rows)
+
+	counts := make(map[uint]map[string]int64, len(postIDs))
+	for _, postID := range postIDs {
+		counts[postID] = emptyReactionCounts()
+	}
+	for _, row := range rows {
+		counts[row.PostID][row.Type] = row.Count
+	}
+	return counts
+}
+
+// 写入Redis计数（所有类型都写入，保证Hash非空）
+func cacheReactionCounts(ctx context.Context, postID uint, counts map[string]int64) {
+	values := make(map[string]interface{}, len(models.ReactionTypes))
+	for _, reactionType := range models.ReactionTypes {
+		values[reactionType] = counts[reactionType]
+	}
+	config.Redis.HSet(ctx, ReactionCountsKey(postID), values)
+}
+
+// 解析Redis中的计数
+func parseReactionCounts(values map[string]string) map[string]int64 {
+	counts := emptyReactionCounts()
+	for reactionType, value := range values {
+		if count, err := strconv.ParseInt(value, 10, 64); err == nil && count > 0 {
+			counts[reactionType] = count
+		}
+	}
+	return counts
+}
+
+// 所有类型计数为0的初始值
+func emptyReactionCounts() map[string]int64 {
+	counts := make(map[string]int64, len(models.ReactionTypes))
+	for _, reactionType := range models.ReactionTypes {
+		counts[reactionType] = 0
+	}
+	return counts
+}
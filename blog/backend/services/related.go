@@ -0,0 +1,205 @@
+package services
+
+import (
+	"blog/config"
+	"blog/models"
+	"blog/utils"
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	// 相关文章重新计算的间隔
+	RelatedPostsInterval = time.Hour
+	// 每篇文章保留的相关文章数量
+	RelatedPostsLimit = 10
+
+	// 相似度各部分的权重：标签、分类、标题与摘要的文本相似度
+	relatedTagWeight      = 0.4
+	relatedCategoryWeight = 0.2
+	relatedTextWeight     = 0.4
+)
+
+// RelatedPostsKey 返回文章相关推荐在Redis中的缓存键
+func RelatedPostsKey(postID uint) string {
+	return fmt.Sprintf("post_related:%d", postID)
+}
+
+// 参与计算的文章特征
+type relatedDoc struct {
+	id         uint
+	tags       map[uint]bool
+	categories map[uint]bool
+	vector     map[string]float64
+	norm       float64
+}
+
+// StartRelatedPostsWorker 启动后台任务，定期预计算所有已发布文章的相关推荐
+func StartRelatedPostsWorker() {
+	go func() {
+		for {
+			if err := ComputeRelatedPosts(); err != nil {
+				log.Printf("计算相关文章失败: %v", err)
+			}
+			time.Sleep(RelatedPostsInterval)
+		}
+	}()
+}
+
+// ComputeRelatedPosts 基于共同标签、分类以及标题和摘要的TF-IDF相似度计算相关文章，并写入Redis
+func ComputeRelatedPosts() error {
+	var posts []models.Post
+	if err := config.DB.Select("id, title, summary").
+		Where("status = ?", "published").
+		Preload("Tags").Preload("Categories").
+		Find(&posts).Error; err != nil {
+		return err
+	}
+
+	docs := buildRelatedDocs(posts)
+	ctx := context.Background()
+
+	// 文章数量有限，直接两两比较
+	for i := range docs {
+		type scored struct {
+			id    uint
+			score float64
+		}
+		var candidates []scored
+		for j := range docs {
+			if i == j {
+				continue
+			}
+			if score := relatedScore(&docs[i], &docs[j]); score > 0 {
+				candidates = append(candidates, scored{id: docs[j].id, score: score})
+			}
+		}
+
+		sort.Slice(candidates, func(a, b int) bool {
+			return candidates[a].score > candidates[b].score
+		})
+		if len(candidates) > RelatedPostsLimit {
+			candidates = candidates[:RelatedPostsLimit]
+		}
+
+		ids := make([]uint, 0, len(candidates))
+		for _, candidate := range candidates {
+			ids = append(ids, candidate.id)
+		}
+
+		data, err := utils.ToJSON(ids)
+		if err != nil {
+			return err
+		}
+		// 缓存有效期为计算间隔的两倍，保证下次计算前缓存不会失效
+		config.Redis.Set(ctx, RelatedPostsKey(docs[i].id), data, RelatedPostsInterval*2)
+	}
+
+	log.Printf("相关文章计算完成，共%d篇文章", len(docs))
+	return nil
+}
+
+// GetRelatedPostIDs 从Redis读取预计算的相关文章ID，缓存不存在时返回nil
+func GetRelatedPostIDs(postID uint) []uint {
+	data, err := config.Redis.Get(context.Background(), RelatedPostsKey(postID)).Result()
+	if err != nil {
+		return nil
+	}
+
+	var ids []uint
+	if err := utils.ParseJSON(data, &ids); err != nil {
+		return nil
+	}
+	return ids
+}
+
+// 构建每篇文章的标签、分类集合以及TF-IDF向量
+func buildRelatedDocs(posts []models.Post) []relatedDoc {
+	docs := make([]relatedDoc, len(posts))
+	termCounts := make([]map[string]int, len(posts))
+	docFreq := make(map[string]int)
+
+	for i, post := range posts {
+		docs[i] = relatedDoc{
+			id:         post.ID,
+			tags:       make(map[uint]bool, len(post.Tags)),
+			categories: make(map[uint]bool, len(post.Categories)),
+		}
+		for _, tag := range post.Tags {
+			docs[i].tags[tag.ID] = true
+		}
+		for _, category := range post.Categories {
+			docs[i].categories[category.ID] = true
+		}
+
+		// 标题中的词条计两次，提高标题的权重
+		counts := make(map[string]int)
+		for _, term := range utils.SearchTerms(post.Title) {
+			counts[term] += 2
+		}
+		for _, term := range utils.SearchTerms(post.Summary) {
+			counts[term]++
+		}
+		termCounts[i] = counts
+		for term := range counts {
+			docFreq[term]++
+		}
+	}
+
+	total := float64(len(posts))
+	for i, counts := range termCounts {
+		vector := make(map[string]float64, len(counts))
+		var sum float64
+		for term, count := range counts {
+			idf := math.Log(total/float64(docFreq[term])) + 1
+			weight := float64(count) * idf
+			vector[term] = weight
+			sum += weight * weight
+		}
+		docs[i].vector = vector
+		docs[i].norm = math.Sqrt(sum)
+	}
+
+	return docs
+}
+
+// 计算两篇文章的相似度
+func relatedScore(a, b *relatedDoc) float64 {
+	return relatedTagWeight*jaccard(a.tags, b.tags) +
+		relatedCategoryWeight*jaccard(a.categories, b.categories) +
+		relatedTextWeight*cosine(a, b)
+}
+
+// 集合的Jaccard相似度
+func jaccard(a, b map[uint]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for id := range a {
+		if b[id] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+// TF-IDF向量的余弦相似度
+func cosine(a, b *relatedDoc) float64 {
+	if a.norm == 0 || b.norm == 0 {
+		return 0
+	}
+	small, large := a.vector, b.vector
+	if len(small) > len(large) {
+		small, large = large, small
+	}
+	var dot float64
+	for term, weight := range small {
+		dot += weight * large[term]
+	}
+	return dot / (a.norm * b.norm)
+}
@@ -0,0 +1,35 @@
+package services
+
+import (
+	"blog/config"
+	"blog/models"
+	"os"
+)
+
+// ReviewRequired 普通作者发布文章是否需要经过审核，通过环境变量 BLOG_REVIEW_REQUIRED=true 开启
+// 编辑和管理员始终可以直接发布
+func ReviewRequired() bool {
+	return os.Getenv("BLOG_REVIEW_REQUIRED") == "true"
+}
+
+// CanPublishDirectly 用户能否不经审核直接发布文章
+func CanPublishDirectly(user *models.User) bool {
+	return !ReviewRequired() || IsEditor(user.ID)
+}
+
+// IsEditor 用户当前是否为编辑或管理员
+// 从数据库读取角色而不是使用令牌中的角色，角色被修改后立即生效
+func IsEditor(userID uint) bool {
+	var user models.User
+	if err := config.DB.Select("id, role").First(&user, userID).Error; err != nil {
+		return false
+	}
+	return user.IsEditor()
+}
+
+// EditorIDs 返回所有编辑和管理员的用户ID，用于通知未指定审核人的审核请求
+func EditorIDs() []uint {
+	var ids []uint
+	config.DB.Model(&models.User{}).Where("role IN ?", []string{models.RoleEditor, models.RoleAdmin}).Pluck("id", &ids)
+	return ids
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"blog/config"
+	"blog/models"
+	"regexp"
+	"strings"
+)
+
+// 正文中引用片段的短代码，如 {{< snippet weekly-footer >}}，名称可以加引号
+var snippetShortcode = regexp.MustCompile(`\{\{<\s*snippet\s+"?([a-z0-9_-]+)"?\s*>\}\}`)
+
+// ExpandShortcodes 把正文中的片段短代码替换为片段内容
+func ExpandShortcodes(content string) string {
+	ExpandShortcodesAll([]*string{&content})
+	return content
+}
+
+// ExpandShortcodesAll 批量展开多篇正文中的片段短代码，所有引用的片段只查询一次
+// 代码块（``` 和 ~~~ 围栏）和行内代码中的短代码保持原样；不存在的片段保持原样；片段内容中的短代码不会再次展开
+func ExpandShortcodesAll(contents []*string) {
+	var names []string
+	for _, content := range contents {
+		if strings.Contains(*content, "{{<") {
+			replaceShortcodes(*content, func(name, code string) string {
+				names = append(names, name)
+				return code
+			})
+		}
+	}
+	if len(names) == 0 {
+		return
+	}
+
+	var snippets []models.Snippet
+	config.DB.Select("name, content").Where("name IN ?", names).Find(&snippets)
+	byName := make(map[string]string, len(snippets))
+	for _, snippet := range snippets {
+		byName[snippet.Name] = strings.TrimRight(snippet.Content, "\n")
+	}
+
+	for _, content := range contents {
+		if !strings.Contains(*content, "{{<") {
+			continue
+		}
+		*content = replaceShortcodes(*content, func(name, code string) string {
+			if snippet, ok := byName[name]; ok {
+				return snippet
+			}
+			return code
+		})
+	}
+}
+
+// 对代码块和行内代码以外的短代码调用 replace，返回替换后的正文
+func replaceShortcodes(content string, replace func(name, code string) string) string {
+	lines := strings.Split(content, "\n")
+	fence := "" // 当前所在代码块的围栏，为空表示不在代码块中
+	for i, line := range lines {
+		trimmed := strings.TrimLeft(line, " ")
+		if marker := fenceMarker(trimmed); marker != "" {
+			if fence == "" {
+				fence = marker
+				continue
+			}
+			// 结束围栏使用相同字符、长度不小于开始围栏，且后面没有其他内容
+			if marker[0] == fence[0] && len(marker) >= len(fence) && strings.TrimSpace(trimmed[len(marker):]) == "" {
+				fence = ""
+				continue
+			}
+		}
+		if fence != "" {
+			continue
+		}
+		lines[i] = replaceOutsideCodeSpans(line, replace)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// 行首的代码块围栏（三个及以上的 ` 或 ~），不是围栏时返回空字符串
+func fenceMarker(line string) string {
+	if line == "" || (line[0] != '`' && line[0] != '~') {
+		return ""
+	}
+	n := 0
+	for n < len(line) && line[n] == line[0] {
+		n++
+	}
+	if n < 3 {
+		return ""
+	}
+	return line[:n]
+}
+
+// 替换一行中行内代码以外的短代码；行内代码以若干个 ` 开始，到相同数量的 ` 结束
+func replaceOutsideCodeSpans(line string, replace func(name, code string) string) string {
+	expand := func(text string) string {
+		return snippetShortcode.ReplaceAllStringFunc(text, func(code string) string {
+			return replace(snippetShortcode.FindStringSubmatch(code)[1], code)
+		})
+	}
+
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(line, '`')
+		if start < 0 {
+			b.WriteString(expand(line))
+			return b.String()
+		}
+		n := start
+		for n < len(line) && line[n] == '`' {
+			n++
+		}
+		ticks := line[start:n]
+		end := closingTicks(line[n:], len(ticks))
+		if end < 0 {
+			// 没有闭合的反引号按普通文本处理
+			b.WriteString(expand(line[:n]))
+			line = line[n:]
+			continue
+		}
+		b.WriteString(expand(line[:start]))
+		b.WriteString(line[start : n+end+len(ticks)])
+		line = line[n+end+len(ticks):]
+	}
+}
+
+// 查找恰好 count 个连续反引号的位置，找不到时返回 -1
+func closingTicks(text string, count int) int {
+	for i := 0; i < len(text); {
+		if text[i] != '`' {
+			i++
+			continue
+		}
+		j := i
+		for j < len(text) && text[j] == '`' {
+			j++
+		}
+		if j-i == count {
+			return i
+		}
+		i = j
+	}
+	return -1
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"blog/config"
+	"blog/models"
+	"blog/storage"
+	"blog/utils"
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// AvatarMigrationItem 单个用户头像的迁移结果
+type AvatarMigrationItem struct {
+	UserID   uint   `json:"userId"`
+	Username string `json:"username"`
+	From     string `json:"from"`
+	To       string `json:"to,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// AvatarMigrationReport 头像迁移报告
+type AvatarMigrationReport struct {
+	DryRun   bool                  `json:"dryRun"`
+	Migrated int                   `json:"migrated"`
+	Failed   int                   `json:"failed"`
+	Items    []AvatarMigrationItem `json:"items"`
+}
+
+// MigrateAvatars 把本地磁盘上的头像上传到当前的对象存储，并改写 User.Avatar
+// localDir 为原上传目录；文件在存储中的路径与原目录下的相对路径相同，因此经由 /uploads 的旧链接仍然有效
+// commit 为false时只生成报告；removeLocal 为true时上传成功后删除本地文件
+func MigrateAvatars(localDir string, commit, removeLocal bool) (*AvatarMigrationReport, error) {
+	if _, ok := config.Storage.(*storage.LocalStorage); ok {
+		return nil, errors.New("当前使用本地存储，请先配置 BLOG_STORAGE=s3")
+	}
+
+	var users []models.User
+	if err := config.DB.Where("avatar LIKE ?", config.UploadsURL+"/%").Order("id").Find(&users).Error; err != nil {
+		return nil, err
+	}
+
+	report := &AvatarMigrationReport{DryRun: !commit, Items: []AvatarMigrationItem{}}
+	ctx := context.Background()
+	for _, user := range users {
+		item := AvatarMigrationItem{UserID: user.ID, Username: user.Username, From: user.Avatar}
+		err := func() error {
+			rel, err := url.PathUnescape(strings.TrimPrefix(user.Avatar, config.UploadsURL+"/"))
+			if err != nil {
+				return err
+			}
+			key, err := storage.CleanKey(rel)
+			if err != nil {
+				return err
+			}
+
+			source := filepath.Join(localDir, filepath.FromSlash(key))
+			data, err := os.ReadFile(source)
+			if err != nil {
+				return err
+			}
+			item.To = config.Storage.URL(key)
+			if !commit {
+				return nil
+			}
+
+			mimeType, _, _ := utils.DetectImageType(data)
+			if err := config.Storage.Put(ctx, key, data, mimeType); err != nil {
+				return err
+			}
+			if item.To != user.Avatar {
+				if err := config.DB.Model(&user).UpdateColumn("avatar", item.To).Error; err != nil {
+					return err
+				}
+			}
+			if removeLocal {
+				os.Remove(source)
+			}
+			return nil
+		}()
+
+		if err != nil {
+			item.Error = err.Error()
+			report.Failed++
+		} else {
+			report.Migrated++
+		}
+		report.Items = append(report.Items, item)
+	}
+
+	return report, nil
+}
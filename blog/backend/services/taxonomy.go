@@ -0,0 +1,55 @@
+package services
+
+import (
+	"blog/models"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// FindOrCreateTags 按名称查找标签，不存在的自动创建；空名称和重复的名称会被忽略
+func FindOrCreateTags(tx *gorm.DB, names []string) ([]models.Tag, error) {
+	tags := make([]models.Tag, 0, len(names))
+	for _, name := range uniqueNames(names) {
+		var tag models.Tag
+		if tx.Where("name = ?", name).First(&tag).Error != nil {
+			tag = models.Tag{Name: name}
+			if err := tx.Create(&tag).Error; err != nil {
+				return nil, err
+			}
+		}
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+// FindOrCreateCategories 按名称查找分类，不存在的自动创建
+func FindOrCreateCategories(tx *gorm.DB, names []string) ([]models.Category, error) {
+	categories := make([]models.Category, 0, len(names))
+	for _, name := range uniqueNames(names) {
+		var category models.Category
+		if tx.Where("name = ?", name).First(&category).Error != nil {
+			category = models.Category{Name: name}
+			if err := tx.Create(&category).Error; err != nil {
+				return nil, err
+			}
+		}
+		categories = append(categories, category)
+	}
+	return categories, nil
+}
+
+// 去除首尾空白、空名称和重复名称，保持原有顺序
+func uniqueNames(names []string) []string {
+	result := make([]string, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		result = append(result, name)
+	}
+	return result
+}
@@ -0,0 +1,28 @@
+package services
+
+import (
+	"blog/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RenderTitlePattern 根据模板生成文章标题，支持的占位符：
+//
+//	{{date}}      当天日期，如 2006-01-02
+//	{{year}}、{{month}}、{{day}}  年、月、日（月、日补零）
+//	{{week}}      ISO 周数
+//	{{n}}         该模板创建的第几篇文章
+//	{{username}}  当前用户名
+func RenderTitlePattern(template *models.PostTemplate, user *models.User, now time.Time) string {
+	_, week := now.ISOWeek()
+	return strings.NewReplacer(
+		"{{date}}", now.Format("2006-01-02"),
+		"{{year}}", now.Format("2006"),
+		"{{month}}", now.Format("01"),
+		"{{day}}", now.Format("02"),
+		"{{week}}", strconv.Itoa(week),
+		"{{n}}", strconv.Itoa(template.UseCount+1),
+		"{{username}}", user.Username,
+	).Replace(template.TitlePattern)
+}
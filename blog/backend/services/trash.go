@@ -0,0 +1,199 @@
+package services
+
+import (
+	"blog/config"
+	"blog/models"
+	"context"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TrashRetentionDays 回收站保留天数，超过后由后台任务永久删除，可通过环境变量 BLOG_TRASH_RETENTION_DAYS 配置
+func TrashRetentionDays() int {
+	if days, err := strconv.Atoi(os.Getenv("BLOG_TRASH_RETENTION_DAYS")); err == nil && days > 0 {
+		return days
+	}
+	return 30
+}
+
+// StartTrashRetentionWorker 启动后台任务，每天清理超过保留期限的已删除文章和评论
+func StartTrashRetentionWorker() {
+	go func() {
+		for {
+			if err := PurgeExpiredTrash(); err != nil {
+				log.Printf("清理回收站失败: %v", err)
+			}
+			time.Sleep(24 * time.Hour)
+		}
+	}()
+}
+
+// PurgeExpiredTrash 永久删除超过保留期限的文章和评论
+func PurgeExpiredTrash() error {
+	before := time.Now().AddDate(0, 0, -TrashRetentionDays())
+
+	var postIDs []uint
+	if err := config.DB.Unscoped().Model(&models.Post{}).
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+		Pluck("id", &postIDs).Error; err != nil {
+		return err
+	}
+
+	var commentIDs []uint
+	if err := config.DB.Unscoped().Model(&models.Comment{}).
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+		Pluck("id", &commentIDs).Error; err != nil {
+		return err
+	}
+
+	if len(postIDs) == 0 && len(commentIDs) == 0 {
+		return nil
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := PurgeComments(tx, commentIDs); err != nil {
+			return err
+		}
+		return PurgePosts(tx, postIDs)
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("回收站清理完成：文章%d篇，评论%d条", len(postIDs), len(commentIDs))
+	return nil
+}
+
+// PurgePosts 永久删除文章及其所有关联数据（评论、通知、收藏、表态、标签和分类关联等）
+func PurgePosts(tx *gorm.DB, postIDs []uint) error {
+	if len(postIDs) == 0 {
+		return nil
+	}
+
+	var commentIDs []uint
+	if err := tx.Unscoped().Model(&models.Comment{}).Where("post_id IN ?", postIDs).Pluck("id", &commentIDs).Error; err != nil {
+		return err
+	}
+	if err := PurgeComments(tx, commentIDs); err != nil {
+		return err
+	}
+
+	// 删除通知、收藏、表态和浏览统计
+	if err := tx.Unscoped().Where("post_id IN ?", postIDs).Delete(&models.Notification{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("post_id IN ?", postIDs).Delete(&models.Favorite{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("post_id IN ?", postIDs).Delete(&models.Reaction{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("post_id IN ?", postIDs).Delete(&models.PostReactionCount{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("post_id IN ?", postIDs).Delete(&models.PostAuthor{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("post_id IN ?", postIDs).Delete(&models.ReviewComment{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("post_id IN ?", postIDs).Delete(&models.PostPreviewLink{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("post_id IN ?", postIDs).Delete(&models.PostViewDaily{}).Error; err != nil {
+		return err
+	}
+
+	if err := promoteTranslations(tx, postIDs); err != nil {
+		return err
+	}
+
+	// 解除系列、标签和分类关联
+	if err := tx.Where("post_id IN ?", postIDs).Delete(&models.SeriesPost{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Exec("DELETE FROM post_tags WHERE post_id IN ?", postIDs).Error; err != nil {
+		return err
+	}
+	if err := tx.Exec("DELETE FROM post_categories WHERE post_id IN ?", postIDs).Error; err != nil {
+		return err
+	}
+
+	// 删除文章
+	if err := tx.Unscoped().Where("id IN ?", postIDs).Delete(&models.Post{}).Error; err != nil {
+		return err
+	}
+
+	// 清理缓存
+	ctx := context.Background()
+	for _, id := range postIDs {
+		config.Redis.Del(ctx, PostCacheKey(id), ReactionCountsKey(id), RelatedPostsKey(id))
+	}
+
+	return nil
+}
+
+// PurgeComments 永久删除评论、其全部回复以及相关通知
+func PurgeComments(tx *gorm.DB, commentIDs []uint) error {
+	if len(commentIDs) == 0 {
+		return nil
+	}
+
+	// 收集所有层级的回复
+	ids := append([]uint{}, commentIDs...)
+	parents := commentIDs
+	for len(parents) > 0 {
+		var replyIDs []uint
+		if err := tx.Unscoped().Model(&models.Comment{}).
+			Where("parent_id IN ? AND id NOT IN ?", parents, ids).
+			Pluck("id", &replyIDs).Error; err != nil {
+			return err
+		}
+		ids = append(ids, replyIDs...)
+		parents = replyIDs
+	}
+
+	if err := tx.Unscoped().Where("comment_id IN ?", ids).Delete(&models.Notification{}).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Where("id IN ?", ids).Delete(&models.Comment{}).Error
+}
+
+// PostCacheKey 返回文章详情在Redis中的缓存键
+func PostCacheKey(postID uint) string {
+	return "post:" + strconv.FormatUint(uint64(postID), 10)
+}
+
+// 永久删除翻译组的原文时，把剩余译文中ID最小的一篇提升为新的原文，其余译文改为指向它
+func promoteTranslations(tx *gorm.DB, postIDs []uint) error {
+	var translations []models.Post
+	if err := tx.Unscoped().Select("id, translation_of").
+		Where("translation_of IN ? AND id NOT IN ?", postIDs, postIDs).
+		Order("id").Find(&translations).Error; err != nil {
+		return err
+	}
+
+	promoted := make(map[uint]bool) // 已处理的原文ID
+	for _, translation := range translations {
+		rootID := *translation.TranslationOf
+		if promoted[rootID] {
+			continue
+		}
+		promoted[rootID] = true
+
+		if err := tx.Unscoped().Model(&models.Post{}).
+			Where("translation_of = ? AND id NOT IN ? AND id <> ?", rootID, postIDs, translation.ID).
+			Update("translation_of", translation.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&models.Post{}).Where("id = ?", translation.ID).
+			Update("translation_of", nil).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,244 @@
+package services
+
+import (
+	"blog/config"
+	"blog/models"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// 同一访客在此时间内重复访问同一文章只计一次浏览
+	ViewDedupWindow = 30 * time.Minute
+	// 缓冲的浏览量写入数据库的间隔
+	ViewFlushInterval = time.Minute
+
+	// 待写入的浏览量（Hash，字段为 文章ID:日期）
+	viewPendingKey = "post_views:pending"
+	// 正在写入的浏览量，写入成功后删除；进程在写入过程中退出时，下次写入会先处理它
+	viewFlushingKey = "post_views:flushing"
+	// 写入锁，避免多个实例同时写入
+	viewFlushLockKey = "post_views:flush_lock"
+	// 单次写入的最长时间，超时后放弃本次写入，数据留待下次重试
+	viewFlushTimeout = 5 * time.Minute
+	// 写入锁的有效期，必须长于单次写入的最长时间，避免写入未完成时锁被其他实例取得
+	viewFlushLockTTL = 2 * viewFlushTimeout
+	// 每日独立访客HyperLogLog的保留时间
+	visitorHLLTTL = 48 * time.Hour
+)
+
+// 写入后同步更新文章详情缓存中的浏览量（缓存不存在时不创建）
+var incrCachedViews = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 1 then
+	return redis.call('HINCRBY', KEYS[1], 'view_count', ARGV[1])
+end
+return 0`)
+
+// 只释放自己持有的锁：值与加锁时写入的令牌一致时才删除
+var releaseLock = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0`)
+
+// VisitorID 访客标识：登录用户使用用户ID，匿名访客使用IP和User-Agent的哈希
+func VisitorID(userID uint, ip, userAgent string) string {
+	if userID != 0 {
+		return "u" + strconv.FormatUint(uint64(userID), 10)
+	}
+	sum := sha256.Sum256([]byte(ip + "|" + userAgent))
+	return "v" + hex.EncodeToString(sum[:12])
+}
+
+// RecordPostView 记录一次文章浏览，同一访客在去重窗口内只计一次，返回是否计数
+// 浏览量先缓冲在Redis中，由后台任务定期写入数据库
+func RecordPostView(postID uint, visitorID string) bool {
+	ctx := context.Background()
+	day := viewDay(time.Now())
+
+	// 每日独立访客
+	hllKey := dailyVisitorsKey(postID, day)
+	config.Redis.PFAdd(ctx, hllKey, visitorID)
+	config.Redis.Expire(ctx, hllKey, visitorHLLTTL)
+
+	seenKey := fmt.Sprintf("post_view_seen:%d:%s", postID, visitorID)
+	counted, err := config.Redis.SetNX(ctx, seenKey, 1, ViewDedupWindow).Result()
+	if err != nil || !counted {
+		return false
+	}
+
+	config.Redis.HIncrBy(ctx, viewPendingKey, pendingViewField(postID, day), 1)
+	return true
+}
+
+// PendingViews 返回文章今天尚未写入数据库的浏览量
+func PendingViews(postID uint) uint {
+	count, _ := config.Redis.HGet(context.Background(), viewPendingKey, pendingViewField(postID, viewDay(time.Now()))).Uint64()
+	return uint(count)
+}
+
+// DailyVisitors 返回文章某天的独立访客数（仅保留最近两天）
+func DailyVisitors(postID uint, day string) uint {
+	count, _ := config.Redis.PFCount(context.Background(), dailyVisitorsKey(postID, day)).Result()
+	return uint(count)
+}
+
+// StartViewWorker 启动后台任务，定期把缓冲的浏览量写入数据库
+func StartViewWorker() {
+	go func() {
+		ticker := time.NewTicker(ViewFlushInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := FlushViews(); err != nil {
+				log.Printf("写入浏览量失败: %v", err)
+			}
+		}
+	}()
+}
+
+// FlushViews 把缓冲的浏览量累加到文章浏览量和每日统计中，服务退出前也应调用一次
+func FlushViews() error {
+	ctx, cancel := context.WithTimeout(context.Background(), viewFlushTimeout)
+	defer cancel()
+
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return err
+	}
+	lockToken := hex.EncodeToString(token)
+	locked, err := config.Redis.SetNX(ctx, viewFlushLockKey, lockToken, viewFlushLockTTL).Result()
+	if err != nil {
+		return err
+	}
+	if !locked {
+		return nil
+	}
+	defer releaseLock.Run(context.Background(), config.Redis, []string{viewFlushLockKey}, lockToken)
+
+	// 上次写入中断时先处理遗留的数据，否则取走当前缓冲的浏览量
+	leftover, err := config.Redis.Exists(ctx, viewFlushingKey).Result()
+	if err != nil {
+		return err
+	}
+	if leftover == 0 {
+		if err := config.Redis.Rename(ctx, viewPendingKey, viewFlushingKey).Err(); err != nil {
+			if strings.Contains(err.Error(), "no such key") {
+				return nil
+			}
+			return err
+		}
+	}
+
+	pending, err := config.Redis.HGetAll(ctx, viewFlushingKey).Result()
+	if err != nil {
+		return err
+	}
+
+	totals := make(map[uint]uint)
+	var daily []models.PostViewDaily
+	for field, value := range pending {
+		postID, day, ok := parsePendingViewField(field)
+		count, err := strconv.ParseUint(value, 10, 64)
+		if !ok || err != nil || count == 0 {
+			continue
+		}
+		date, _ := time.ParseInLocation("2006-01-02", day, time.Local)
+		totals[postID] += uint(count)
+		daily = append(daily, models.PostViewDaily{
+			PostID:   postID,
+			Date:     date,
+			Views:    uint(count),
+			Visitors: DailyVisitors(postID, day),
+		})
+	}
+
+	// 已永久删除的文章不再记录
+	postIDs := make([]uint, 0, len(totals))
+	for postID := range totals {
+		postIDs = append(postIDs, postID)
+	}
+	var existing []uint
+	if len(postIDs) > 0 {
+		if err := config.DB.WithContext(ctx).Unscoped().Model(&models.Post{}).Where("id IN ?", postIDs).Pluck("id", &existing).Error; err != nil {
+			return err
+		}
+	}
+	exists := make(map[uint]bool, len(existing))
+	for _, id := range existing {
+		exists[id] = true
+	}
+
+	err = config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for postID, count := range totals {
+			if !exists[postID] {
+				continue
+			}
+			if err := tx.Unscoped().Model(&models.Post{}).Where("id = ?", postID).
+				UpdateColumn("view_count", gorm.Expr("view_count + ?", count)).Error; err != nil {
+				return err
+			}
+		}
+		for _, stat := range daily {
+			if !exists[stat.PostID] {
+				continue
+			}
+			if err := tx.Clauses(clause.OnConflict{
+				Columns: []clause.Column{{Name: "post_id"}, {Name: "date"}},
+				DoUpdates: clause.Assignments(map[string]interface{}{
+					"views":    gorm.Expr("post_view_dailies.views + EXCLUDED.views"),
+					"visitors": gorm.Expr("GREATEST(post_view_dailies.visitors, EXCLUDED.visitors)"),
+				}),
+			}).Create(&stat).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		// 保留正在写入的数据，下次重试
+		return err
+	}
+
+	// 数据已写入数据库，即使已超时也必须删除，否则下次会重复累加
+	ctx = context.Background()
+	config.Redis.Del(ctx, viewFlushingKey)
+	for postID, count := range totals {
+		if exists[postID] {
+			incrCachedViews.Run(ctx, config.Redis, []string{PostCacheKey(postID)}, count)
+		}
+	}
+	return nil
+}
+
+// 统计日期，使用服务器本地时区
+func viewDay(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+func dailyVisitorsKey(postID uint, day string) string {
+	return fmt.Sprintf("post_uv:%d:%s", postID, day)
+}
+
+func pendingViewField(postID uint, day string) string {
+	return strconv.FormatUint(uint64(postID), 10) + ":" + day
+}
+
+func parsePendingViewField(field string) (uint, string, bool) {
+	id, day, found := strings.Cut(field, ":")
+	postID, err := strconv.ParseUint(id, 10, 64)
+	if !found || err != nil {
+		return 0, "", false
+	}
+	return uint(postID), day, true
+}
@@ -0,0 +1,540 @@
+package services
+
+import (
+	"blog/config"
+	"blog/models"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// WordPress附件在存储中的目录
+const WordPressUploadsDir = "wordpress"
+
+// WordPressImportOptions WordPress导入选项
+type WordPressImportOptions struct {
+	MediaDir string // 本地的 wp-content/uploads 目录，为空时不复制附件、不改写链接
+	Commit   bool   // 为false时只生成预览报告
+}
+
+// WordPressImportReport WordPress导入报告
+type WordPressImportReport struct {
+	DryRun     bool         `json:"dryRun"`
+	Users      int          `json:"users"`      // 新建的占位用户数
+	Posts      int          `json:"posts"`      // 新建的文章数
+	Skipped    int          `json:"skipped"`    // 已存在而跳过的文章数
+	Comments   int          `json:"comments"`   // 导入的评论数
+	Media      int          `json:"media"`      // 复制的附件数
+	Failed     int          `json:"failed"`     // 失败的文章数
+	Tags       int          `json:"tags"`       // 涉及的标签数
+	Categories int          `json:"categories"` // 涉及的分类数
+	Missing    []string     `json:"missing"`    // 本地媒体目录中找不到的附件
+	Items      []ImportItem `json:"items"`      // 每篇文章的结果
+}
+
+// WXR导出文件结构；WordPress不同版本的命名空间不同，这里只按元素名匹配
+type wxrFile struct {
+	Channel struct {
+		Authors []wxrAuthor `xml:"author"`
+		Items   []wxrItem   `xml:"item"`
+	} `xml:"channel"`
+}
+
+type wxrAuthor struct {
+	ID          string `xml:"author_id"`
+	Login       string `xml:"author_login"`
+	Email       string `xml:"author_email"`
+	DisplayName string `xml:"author_display_name"`
+}
+
+type wxrItem struct {
+	Title       string       `xml:"title"`
+	Creator     string       `xml:"creator"`
+	Encoded     []wxrEncoded `xml:"encoded"` // content:encoded 和 excerpt:encoded
+	PostID      string       `xml:"post_id"`
+	PostDate    string       `xml:"post_date"`
+	PostDateGMT string       `xml:"post_date_gmt"`
+	Modified    string       `xml:"post_modified"`
+	ModifiedGMT string       `xml:"post_modified_gmt"`
+	PostName    string       `xml:"post_name"`
+	Status      string       `xml:"status"`
+	PostType    string       `xml:"post_type"`
+	Password    string       `xml:"post_password"`
+	Attachment  string       `xml:"attachment_url"`
+	Terms       []wxrTerm    `xml:"category"`
+	Meta        []wxrMeta    `xml:"postmeta"`
+	Comments    []wxrComment `xml:"comment"`
+}
+
+type wxrEncoded struct {
+	XMLName xml.Name
+	Value   string `xml:",chardata"`
+}
+
+type wxrTerm struct {
+	Domain   string `xml:"domain,attr"`
+	Nicename string `xml:"nicename,attr"`
+	Name     string `xml:",chardata"`
+}
+
+type wxrMeta struct {
+	Key   string `xml:"meta_key"`
+	Value string `xml:"meta_value"`
+}
+
+type wxrComment struct {
+	ID          string `xml:"comment_id"`
+	Author      string `xml:"comment_author"`
+	AuthorEmail string `xml:"comment_author_email"`
+	Date        string `xml:"comment_date"`
+	DateGMT     string `xml:"comment_date_gmt"`
+	Content     string `xml:"comment_content"`
+	Approved    string `xml:"comment_approved"`
+	Type        string `xml:"comment_type"`
+	Parent      string `xml:"comment_parent"`
+	UserID      string `xml:"comment_user_id"`
+}
+
+// 正文或附件中指向WordPress上传目录的链接
+var wpUploadsURL = regexp.MustCompile(`(?:https?:)?//[^\s"'()<>]+/wp-content/uploads/([^\s"'()<>?#]+)`)
+
+// WordPress导入过程中的状态
+type wordpressImporter struct {
+	tx          *gorm.DB
+	opts        WordPressImportOptions
+	report      *WordPressImportReport
+	users       map[string]uint   // 作者登录名 -> 用户ID
+	authorIDs   map[string]string // WordPress用户ID -> 作者登录名
+	commenters  map[string]uint   // 评论者邮箱或名称 -> 用户ID
+	slugs       map[string]bool   // 本次导入已使用的slug
+	usernames   map[string]bool   // 本次新建用户占用的用户名
+	media       map[string]string // 附件相对路径 -> 改写后的链接
+	attachments map[string]string // 附件ID -> 附件URL
+	tags        map[string]bool
+	categories  map[string]bool
+}
+
+// ImportWordPress 导入WordPress的WXR导出文件
+// 作者按登录名或邮箱匹配已有用户，不存在时创建无法登录的占位用户；文章和页面都导入为文章，
+// 按默认语言下的slug判断是否已导入过，已存在的文章会被跳过；附件上传到存储的 wordpress 目录并改写链接
+func ImportWordPress(r io.Reader, opts WordPressImportOptions) (*WordPressImportReport, error) {
+	var file wxrFile
+	if err := xml.NewDecoder(r).Decode(&file); err != nil {
+		return nil, fmt.Errorf("解析WXR文件失败: %v", err)
+	}
+
+	report := &WordPressImportReport{DryRun: !opts.Commit, Missing: []string{}, Items: []ImportItem{}}
+	im := &wordpressImporter{
+		opts:        opts,
+		report:      report,
+		users:       make(map[string]uint),
+		authorIDs:   make(map[string]string),
+		commenters:  make(map[string]uint),
+		slugs:       make(map[string]bool),
+		usernames:   make(map[string]bool),
+		media:       make(map[string]string),
+		attachments: make(map[string]string),
+		tags:        make(map[string]bool),
+		categories:  make(map[string]bool),
+	}
+
+	for _, item := range file.Channel.Items {
+		if item.PostType == "attachment" {
+			im.attachments[item.PostID] = item.Attachment
+		}
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		im.tx = tx
+
+		for _, author := range file.Channel.Authors {
+			if _, err := im.mapAuthor(author); err != nil {
+				return fmt.Errorf("导入作者 %s 失败: %v", author.Login, err)
+			}
+		}
+
+		for _, item := range file.Channel.Items {
+			if item.PostType != "post" && item.PostType != "page" {
+				continue
+			}
+			if item.Status == "trash" || item.Status == "auto-draft" || item.Status == "inherit" {
+				continue
+			}
+			if err := im.importItem(item); err != nil {
+				return err
+			}
+		}
+
+		if !opts.Commit {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && err != errDryRun {
+		return nil, err
+	}
+
+	report.Tags = len(im.tags)
+	report.Categories = len(im.categories)
+	return report, nil
+}
+
+// 导入一篇文章或页面及其评论
+func (im *wordpressImporter) importItem(item wxrItem) error {
+	// WordPress允许文章和页面使用相同的别名，同一次导入中重复时改用 wp-<类型>-<ID>
+	slug := wpSlug(item)
+	if im.slugs[slug] {
+		slug = "wp-" + item.PostType + "-" + item.PostID
+	}
+	im.slugs[slug] = true
+	result := ImportItem{File: "wp:" + item.PostType + ":" + item.PostID, Slug: slug, Title: item.Title}
+
+	var count int64
+	im.tx.Model(&models.Post{}).Where("slug = ? AND language = ?", slug, models.DefaultLanguage).Count(&count)
+	if count > 0 {
+		result.Action = "skip"
+		im.report.Skipped++
+		im.report.Items = append(im.report.Items, result)
+		return nil
+	}
+
+	content, excerpt := "", ""
+	for _, encoded := range item.Encoded {
+		if strings.Contains(encoded.XMLName.Space, "excerpt") {
+			excerpt = encoded.Value
+		} else {
+			content = encoded.Value
+		}
+	}
+	if strings.TrimSpace(item.Title) == "" || strings.TrimSpace(content) == "" {
+		result.Action = ImportActionError
+		result.Error = "标题或正文为空"
+		im.report.Failed++
+		im.report.Items = append(im.report.Items, result)
+		return nil
+	}
+
+	userID, ok := im.users[item.Creator]
+	if !ok {
+		var err error
+		if userID, err = im.mapAuthor(wxrAuthor{Login: item.Creator}); err != nil {
+			return err
+		}
+	}
+
+	createdAt := wpTime(item.PostDateGMT, item.PostDate)
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	updatedAt := wpTime(item.ModifiedGMT, item.Modified)
+	if updatedAt.Before(createdAt) {
+		updatedAt = createdAt
+	}
+
+	post := models.Post{
+		Title:      truncateRunes(strings.TrimSpace(item.Title), 200),
+		Slug:       slug,
+		Language:   models.DefaultLanguage,
+		Content:    im.rewriteMedia(content),
+		Summary:    truncateRunes(strings.TrimSpace(excerpt), 500),
+		Status:     "published",
+		Visibility: models.VisibilityPublic,
+		UserID:     userID,
+		CreatedAt:  createdAt,
+		UpdatedAt:  updatedAt,
+	}
+	switch item.Status {
+	case "publish":
+	case "private":
+		post.Visibility = models.VisibilityPrivate
+	default: // draft、pending、future
+		post.Status = "draft"
+	}
+	if item.Password != "" && post.Visibility == models.VisibilityPublic {
+		post.Visibility = models.VisibilityPassword
+		if err := post.SetAccessPassword(item.Password); err != nil {
+			return err
+		}
+	}
+	for _, meta := range item.Meta {
+		if meta.Key == "_thumbnail_id" && im.attachments[meta.Value] != "" {
+			post.Cover = im.rewriteMedia(im.attachments[meta.Value])
+		}
+	}
+	post.ComputeContentStats()
+
+	var tagNames, categoryNames []string
+	for _, term := range item.Terms {
+		switch term.Domain {
+		case "post_tag":
+			tagNames = append(tagNames, term.Name)
+			im.tags[term.Name] = true
+		case "category":
+			if term.Nicename == "uncategorized" {
+				continue
+			}
+			categoryNames = append(categoryNames, term.Name)
+			im.categories[term.Name] = true
+		}
+	}
+
+	if err := im.tx.Create(&post).Error; err != nil {
+		return fmt.Errorf("创建文章《%s》失败: %v", item.Title, err)
+	}
+	if err := setImportedTaxonomy(im.tx, &post, tagNames, categoryNames, updatedAt); err != nil {
+		return fmt.Errorf("设置文章《%s》的标签和分类失败: %v", item.Title, err)
+	}
+
+	if err := im.importComments(post.ID, item.Comments); err != nil {
+		return fmt.Errorf("导入文章《%s》的评论失败: %v", item.Title, err)
+	}
+
+	result.Action = ImportActionCreate
+	result.PostID = post.ID
+	im.report.Posts++
+	im.report.Items = append(im.report.Items, result)
+	return nil
+}
+
+// 导入已通过审核的评论，按WordPress中的父评论关系设置ParentID
+func (im *wordpressImporter) importComments(postID uint, comments []wxrComment) error {
+	var pending []wxrComment
+	for _, comment := range comments {
+		if comment.Approved == "1" && (comment.Type == "" || comment.Type == "comment") {
+			pending = append(pending, comment)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		a, _ := strconv.Atoi(pending[i].ID)
+		b, _ := strconv.Atoi(pending[j].ID)
+		return a < b
+	})
+
+	// 父评论必须先创建；父评论未通过审核时作为顶级评论导入
+	approved := make(map[string]bool, len(pending))
+	for _, comment := range pending {
+		approved[comment.ID] = true
+	}
+	created := make(map[string]uint, len(pending))
+	for len(pending) > 0 {
+		var deferred []wxrComment
+		for _, comment := range pending {
+			var parentID *uint
+			if comment.Parent != "" && comment.Parent != "0" && approved[comment.Parent] {
+				id, ok := created[comment.Parent]
+				if !ok {
+					deferred = append(deferred, comment)
+					continue
+				}
+				parentID = &id
+			}
+
+			userID, err := im.mapCommenter(comment)
+			if err != nil {
+				return err
+			}
+
+			createdAt := wpTime(comment.DateGMT, comment.Date)
+			if createdAt.IsZero() {
+				createdAt = time.Now()
+			}
+			record := models.Comment{
+				Content:   comment.Content,
+				UserID:    userID,
+				PostID:    postID,
+				ParentID:  parentID,
+				CreatedAt: createdAt,
+				UpdatedAt: createdAt,
+			}
+			if err := im.tx.Create(&record).Error; err != nil {
+				return err
+			}
+			created[comment.ID] = record.ID
+			im.report.Comments++
+		}
+
+		// 父评论形成环或不存在时不再等待
+		if len(deferred) == len(pending) {
+			for i := range deferred {
+				deferred[i].Parent = ""
+			}
+		}
+		pending = deferred
+	}
+	return nil
+}
+
+// 按登录名或邮箱匹配已有用户，不存在时创建占位用户
+func (im *wordpressImporter) mapAuthor(author wxrAuthor) (uint, error) {
+	if id, ok := im.users[author.Login]; ok {
+		return id, nil
+	}
+
+	var user models.User
+	query := im.tx.Where("username = ?", author.Login)
+	if author.Email != "" {
+		query = query.Or("email = ?", author.Email)
+	}
+	if query.First(&user).Error != nil {
+		var err error
+		if user, err = im.createPlaceholderUser(author.Login, author.Email); err != nil {
+			return 0, err
+		}
+	}
+
+	im.users[author.Login] = user.ID
+	if author.ID != "" {
+		im.authorIDs[author.ID] = author.Login
+	}
+	return user.ID, nil
+}
+
+// 评论者：注册用户对应到导入的作者，其他评论者按邮箱（没有邮箱时按名称）创建占位用户
+// 不按邮箱匹配本站已有账户，避免导入的评论显示为本站用户所写
+func (im *wordpressImporter) mapCommenter(comment wxrComment) (uint, error) {
+	if login, ok := im.authorIDs[comment.UserID]; ok && comment.UserID != "0" {
+		return im.users[login], nil
+	}
+
+	key := strings.ToLower(strings.TrimSpace(comment.AuthorEmail))
+	if key == "" {
+		key = "name:" + strings.TrimSpace(comment.Author)
+	}
+	if id, ok := im.commenters[key]; ok {
+		return id, nil
+	}
+
+	// 占位用户不使用评论者的邮箱，该邮箱可能已被本站账户注册
+	user, err := im.createPlaceholderUser(comment.Author, "")
+	if err != nil {
+		return 0, err
+	}
+
+	im.commenters[key] = user.ID
+	return user.ID, nil
+}
+
+// 创建无法登录的占位用户（随机密码），用户名冲突时追加数字后缀
+func (im *wordpressImporter) createPlaceholderUser(name, email string) (models.User, error) {
+	base := truncateRunes(strings.TrimSpace(name), 40)
+	if base == "" {
+		base = "wp-user"
+	}
+
+	username := base
+	for i := 2; ; i++ {
+		var count int64
+		im.tx.Model(&models.User{}).Unscoped().Where("username = ?", username).Count(&count)
+		if count == 0 && !im.usernames[username] {
+			break
+		}
+		username = base + "-" + strconv.Itoa(i)
+	}
+	im.usernames[username] = true
+
+	if email == "" {
+		email = uuid.NewString() + "@wordpress.invalid"
+	}
+
+	user := models.User{
+		Username: username,
+		Email:    email,
+		Password: uuid.NewString(),
+		Role:     "user",
+	}
+	if err := im.tx.Create(&user).Error; err != nil {
+		return user, err
+	}
+	im.report.Users++
+	return user, nil
+}
+
+// 把指向WordPress上传目录的链接改写为本站存储中的链接，并从本地媒体目录上传文件
+func (im *wordpressImporter) rewriteMedia(content string) string {
+	if im.opts.MediaDir == "" {
+		return content
+	}
+
+	return wpUploadsURL.ReplaceAllStringFunc(content, func(link string) string {
+		rel, err := url.PathUnescape(wpUploadsURL.FindStringSubmatch(link)[1])
+		if err != nil || strings.Contains(rel, "..") {
+			return link
+		}
+		if target, ok := im.media[rel]; ok {
+			return target
+		}
+
+		source := filepath.Join(im.opts.MediaDir, filepath.FromSlash(rel))
+		if _, err := os.Stat(source); err != nil {
+			im.report.Missing = append(im.report.Missing, rel)
+			im.media[rel] = link
+			return link
+		}
+
+		key := path.Join(WordPressUploadsDir, rel)
+		target := config.Storage.URL(key)
+		if im.opts.Commit {
+			if err := uploadFile(source, key); err != nil {
+				im.report.Missing = append(im.report.Missing, rel)
+				im.media[rel] = link
+				return link
+			}
+		}
+		im.media[rel] = target
+		im.report.Media++
+		return target
+	})
+}
+
+// 把本地文件上传到存储
+func uploadFile(source, key string) error {
+	data, err := os.ReadFile(source)
+	if err != nil {
+		return err
+	}
+	return config.Storage.Put(context.Background(), key, data, mime.TypeByExtension(path.Ext(key)))
+}
+
+// 文章的slug，WordPress中非ASCII的slug是URL编码的
+func wpSlug(item wxrItem) string {
+	if item.PostName != "" {
+		if slug, err := url.PathUnescape(item.PostName); err == nil {
+			return truncateRunes(slug, 200)
+		}
+		return truncateRunes(item.PostName, 200)
+	}
+	return "wp-" + item.PostType + "-" + item.PostID
+}
+
+// 解析WordPress的时间，优先使用UTC时间，无法解析时返回零值
+func wpTime(gmt, local string) time.Time {
+	if t, err := time.Parse("2006-01-02 15:04:05", gmt); err == nil && t.Year() > 1 {
+		return t
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04:05", local, time.Local); err == nil && t.Year() > 1 {
+		return t
+	}
+	return time.Time{}
+}
+
+func truncateRunes(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	return string([]rune(s)[:max])
+}
@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// LocalStorage 本地磁盘存储，文件通过 BaseURL 下的路由对外提供
+type LocalStorage struct {
+	Dir     string // 存储根目录
+	BaseURL string // 访问链接前缀，如 /uploads
+	secret  []byte // 签名临时链接使用的密钥
+}
+
+// NewLocal 创建本地磁盘存储
+func NewLocal(dir, baseURL string, secret []byte) *LocalStorage {
+	return &LocalStorage{Dir: dir, BaseURL: baseURL, secret: secret}
+}
+
+// Path 返回文件在磁盘上的路径
+func (s *LocalStorage) Path(key string) (string, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(s.Dir, filepath.FromSlash(key)), nil
+}
+
+func (s *LocalStorage) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	target, err := s.Path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(target, data, 0644)
+}
+
+func (s *LocalStorage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	target, err := s.Path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(target)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return file, err
+}
+
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	target, err := s.Path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(target); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStorage) URL(key string) string {
+	return s.BaseURL + "/" + escapeKey(key)
+}
+
+// SignedURL 在链接上附加过期时间和HMAC签名，由 VerifySignature 校验
+func (s *LocalStorage) SignedURL(key string, expires time.Duration) (string, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	expiresAt := strconv.FormatInt(time.Now().Add(expires).Unix(), 10)
+	return fmt.Sprintf("%s?expires=%s&signature=%s", s.URL(key), expiresAt, s.sign(key, expiresAt)), nil
+}
+
+// VerifySignature 校验临时链接的签名是否有效且未过期
+func (s *LocalStorage) VerifySignature(key, expires, signature string) bool {
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(s.sign(key, expires)))
+}
+
+func (s *LocalStorage) sign(key, expires string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(key + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
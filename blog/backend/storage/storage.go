@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// 以此前缀开头的对象为私有对象，只能通过带签名的临时链接访问
+const PrivatePrefix = "private/"
+
+var (
+	ErrNotFound   = errors.New("文件不存在")
+	ErrInvalidKey = errors.New("无效的文件路径")
+)
+
+// Storage 上传文件的存储后端，key为以"/"分隔的相对路径（如 media/2024/01/xxx.jpg）
+type Storage interface {
+	// Put 写入文件，已存在时覆盖
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	// Get 读取文件，不存在时返回ErrNotFound
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete 删除文件，不存在时不报错
+	Delete(ctx context.Context, key string) error
+	// URL 返回可长期保存到数据库中的访问链接
+	URL(key string) string
+	// SignedURL 返回在指定时间内有效的带签名临时链接，用于访问私有对象
+	SignedURL(key string, expires time.Duration) (string, error)
+}
+
+// IsPrivate 是否为私有对象
+func IsPrivate(key string) bool {
+	return strings.HasPrefix(key, PrivatePrefix)
+}
+
+// CleanKey 规范化文件路径，拒绝绝对路径和包含".."的路径
+func CleanKey(key string) (string, error) {
+	key = strings.TrimPrefix(strings.ReplaceAll(key, "\\", "/"), "/")
+	if key == "" {
+		return "", ErrInvalidKey
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return "", ErrInvalidKey
+		}
+	}
+	return path.Clean(key), nil
+}
+
+// 对路径中的每一段分别转义，保留"/"
+func escapeKey(key string) string {
+	return (&url.URL{Path: key}).EscapedPath()
+}
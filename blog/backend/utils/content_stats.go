@@ -0,0 +1,133 @@
+package utils
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// 阅读速度：中日韩文字按每分钟400字，其他语言按每分钟200词
+const (
+	cjkCharsPerMinute = 400
+	wordsPerMinute    = 200
+)
+
+// TOCItem 文章目录中的一个标题
+type TOCItem struct {
+	Level int    `json:"level"` // 标题级别，1-6
+	Text  string `json:"text"`
+	ID    string `json:"id"` // 锚点ID，与渲染后标题的id属性一致
+}
+
+// ContentStats 文章正文的统计信息
+type ContentStats struct {
+	WordCount   int       // 字数：每个中日韩文字计为一个字，其他语言按单词计
+	CharCount   int       // 字符数，不含空白和Markdown标记
+	ReadingTime int       // 预计阅读时间（分钟），正文非空时至少为1
+	TOC         []TOCItem // 按标题生成的目录
+}
+
+var (
+	plainHeading = regexp.MustCompile(`(?s)<h([1-6])>(.*?)</h[1-6]>`)
+	htmlHeading  = regexp.MustCompile(`(?s)<h([1-6]) id="([^"]*)">(.*?)</h[1-6]>`)
+	htmlTag      = regexp.MustCompile(`(?s)<[^>]*>`)
+	rawHTMLTag   = regexp.MustCompile(`</?[a-zA-Z][a-zA-Z0-9-]*(?:\s[^<>]*)?/?>`)
+	htmlBlockTag = regexp.MustCompile(`^</?(?:p|li|ul|ol|td|th|tr|table|thead|tbody|br|hr|h[1-6]|pre|blockquote)\b`)
+)
+
+// AnalyzeMarkdown 统计Markdown正文的字数、字符数和阅读时间，并提取标题目录
+func AnalyzeMarkdown(source string) ContentStats {
+	rendered := RenderMarkdown(source)
+
+	var stats ContentStats
+	for _, match := range htmlHeading.FindAllStringSubmatch(rendered, -1) {
+		level, _ := strconv.Atoi(match[1])
+		stats.TOC = append(stats.TOC, TOCItem{
+			Level: level,
+			Text:  htmlText(match[3]),
+			ID:    html.UnescapeString(match[2]),
+		})
+	}
+
+	// 统计字数时忽略正文中的原始HTML标签（如从WordPress导入的文章）
+	var cjk, words int
+	inWord := false
+	for _, r := range htmlText(RenderMarkdown(rawHTMLTag.ReplaceAllString(source, " "))) {
+		switch {
+		case unicode.IsSpace(r):
+			inWord = false
+			continue
+		case IsCJK(r):
+			cjk++
+			inWord = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if !inWord {
+				words++
+			}
+			inWord = true
+		default:
+			// 标点符号计入字符数，但不计入字数；单词内的撇号和连字符不拆分单词
+			if r != '\'' && r != '-' && r != '’' {
+				inWord = false
+			}
+		}
+		stats.CharCount++
+	}
+
+	stats.WordCount = cjk + words
+	if stats.WordCount > 0 {
+		minutes := float64(cjk)/cjkCharsPerMinute + float64(words)/wordsPerMinute
+		stats.ReadingTime = int(minutes + 0.999)
+		if stats.ReadingTime < 1 {
+			stats.ReadingTime = 1
+		}
+	}
+	return stats
+}
+
+// 去除HTML标签并还原实体，得到纯文本；块级标签替换为空格，避免相邻段落或单元格的文字连在一起
+func htmlText(s string) string {
+	s = htmlTag.ReplaceAllStringFunc(s, func(tag string) string {
+		if htmlBlockTag.MatchString(tag) {
+			return " "
+		}
+		return ""
+	})
+	return strings.TrimSpace(html.UnescapeString(s))
+}
+
+// HeadingAnchor 由标题文本生成锚点ID：转为小写，保留字母（包括中文）、数字、连字符和下划线，空白替换为连字符
+func HeadingAnchor(text string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(text)) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_':
+			b.WriteRune(r)
+		case unicode.IsSpace(r):
+			b.WriteRune('-')
+		}
+	}
+	return b.String()
+}
+
+// 为渲染结果中的标题添加唯一的id属性，重复的锚点依次追加 -1、-2 后缀
+func addHeadingIDs(rendered string) string {
+	used := map[string]int{}
+	return plainHeading.ReplaceAllStringFunc(rendered, func(m string) string {
+		match := plainHeading.FindStringSubmatch(m)
+		id := HeadingAnchor(htmlText(match[2]))
+		if id == "" {
+			id = "section"
+		}
+		if count, ok := used[id]; ok {
+			used[id] = count + 1
+			id += "-" + strconv.Itoa(count+1)
+			used[id] = 0
+		} else {
+			used[id] = 0
+		}
+		return `<h` + match[1] + ` id="` + html.EscapeString(id) + `">` + match[2] + `</h` + match[1] + `>`
+	})
+}
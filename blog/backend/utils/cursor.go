@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Cursor 游标分页的位置，由创建时间和ID共同确定唯一顺序
+type Cursor struct {
+	CreatedAt time.Time
+	ID        uint
+}
+
+// EncodeCursor 将游标编码为对客户端不透明的字符串
+func EncodeCursor(createdAt time.Time, id uint) string {
+	raw := fmt.Sprintf("%d:%d", createdAt.UnixNano(), id)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor 解析客户端传回的游标字符串
+func DecodeCursor(s string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.New("无效的游标")
+	}
+
+	var nanos int64
+	var id uint
+	if _, err := fmt.Sscanf(string(raw), "%d:%d", &nanos, &id); err != nil || id == 0 {
+		return nil, errors.New("无效的游标")
+	}
+
+	return &Cursor{CreatedAt: time.Unix(0, nanos), ID: id}, nil
+}
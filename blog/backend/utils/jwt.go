@@ -1,63 +1,152 @@
-package utils
-
-import (
-	"errors"
-	"time"
-
-	"github.com/golang-jwt/jwt/v5"
-)
-
-// JWT密钥
-var jwtSecret = []byte("blog_secret_key") // 在实际生产环境中应该使用安全的密钥并存储在环境变量中
-
-// Claims自定义JWT声明
-type Claims struct {
-	UserID   uint   `json:"user_id"`
-	Username string `json:"username"`
-	Role     string `json:"role"`
-	jwt.RegisteredClaims
-}
-
-// 生成JWT令牌
-func GenerateToken(userID uint, username, role string) (string, error) {
-	// 设置JWT声明
-	claims := Claims{
-		UserID:   userID,
-		Username: username,
-		Role:     role,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)), // 令牌有效期24小时
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			Subject:   "user_token",
-		},
-	}
-
-	// 创建令牌
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	// 签名并获取完整的编码后的字符串令牌
-	return token.SignedString(jwtSecret)
-}
-
-// 解析JWT令牌
-func ParseToken(tokenString string) (*Claims, error) {
-	// 解析令牌
-	token, err := jwt.ParseWithClaims(
-		tokenString,
-		&Claims{},
-		func(token *jwt.Token) (interface{}, error) {
-			return jwtSecret, nil
-		},
-	)
-
-	if err != nil {
-		return nil, err
-	}
-
-	// 验证令牌
-	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
-		return claims, nil
-	}
-
-	return nil, errors.New("无效令牌")
-}
+package utils
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWT密钥
+var jwtSecret = []byte("blog_secret_key") // 在实际生产环境中应该使用安全的密钥并存储在环境变量中
+
+// Claims自定义JWT声明
+type Claims struct {
+	UserID   uint   `json:"user_id"`
+	Username string `json:"username"`
+	Role     string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// 生成JWT令牌
+func GenerateToken(userID uint, username, role string) (string, error) {
+	// 设置JWT声明
+	claims := Claims{
+		UserID:   userID,
+		Username: username,
+		Role:     role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)), // 令牌有效期24小时
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Subject:   "user_token",
+		},
+	}
+
+	// 创建令牌
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+
+	// 签名并获取完整的编码后的字符串令牌
+	return token.SignedString(jwtSecret)
+}
+
+// 解析JWT令牌
+func ParseToken(tokenString string) (*Claims, error) {
+	// 解析令牌
+	token, err := jwt.ParseWithClaims(
+		tokenString,
+		&Claims{},
+		func(token *jwt.Token) (interface{}, error) {
+			return jwtSecret, nil
+		},
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// 验证令牌
+	if claims, ok := token.Claims.(*Claims); ok && token.Valid && claims.Subject == "user_token" {
+		return claims, nil
+	}
+
+	return nil, errors.New("无效令牌")
+}
+
+// 文章访问令牌声明（用于密码保护的文章）
+type PostAccessClaims struct {
+	PostID uint `json:"post_id"`
+	jwt.RegisteredClaims
+}
+
+// 文章访问令牌有效期
+const PostAccessTokenTTL = 2 * time.Hour
+
+// 生成文章访问令牌
+func GeneratePostAccessToken(postID uint) (string, error) {
+	claims := PostAccessClaims{
+		PostID: postID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(PostAccessTokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Subject:   "post_access",
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(jwtSecret)
+}
+
+// 解析文章访问令牌
+func ParsePostAccessToken(tokenString string) (*PostAccessClaims, error) {
+	token, err := jwt.ParseWithClaims(
+		tokenString,
+		&PostAccessClaims{},
+		func(token *jwt.Token) (interface{}, error) {
+			return jwtSecret, nil
+		},
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if claims, ok := token.Claims.(*PostAccessClaims); ok && token.Valid && claims.Subject == "post_access" {
+		return claims, nil
+	}
+
+	return nil, errors.New("无效的访问令牌")
+}
+
+// 草稿预览令牌声明，LinkID 对应数据库中的预览链接记录，用于撤销
+type PostPreviewClaims struct {
+	PostID uint `json:"post_id"`
+	LinkID uint `json:"link_id"`
+	jwt.RegisteredClaims
+}
+
+// 生成草稿预览令牌
+func GeneratePostPreviewToken(postID, linkID uint, expiresAt time.Time) (string, error) {
+	claims := PostPreviewClaims{
+		PostID: postID,
+		LinkID: linkID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Subject:   "post_preview",
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(jwtSecret)
+}
+
+// 解析草稿预览令牌
+func ParsePostPreviewToken(tokenString string) (*PostPreviewClaims, error) {
+	token, err := jwt.ParseWithClaims(
+		tokenString,
+		&PostPreviewClaims{},
+		func(token *jwt.Token) (interface{}, error) {
+			return jwtSecret, nil
+		},
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if claims, ok := token.Claims.(*PostPreviewClaims); ok && token.Valid && claims.Subject == "post_preview" {
+		return claims, nil
+	}
+
+	return nil, errors.New("无效的预览令牌")
+}
@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// NormalizeLanguage 把语言标签规范为小写的主语言代码，如 zh-CN、zh_Hans 都转换为 zh
+func NormalizeLanguage(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+	return tag
+}
+
+// ParseAcceptLanguage 解析 Accept-Language 请求头，按权重从高到低返回规范化后的语言代码（去重，忽略 * 和 q=0）
+func ParseAcceptLanguage(header string) []string {
+	type weighted struct {
+		lang string
+		q    float64
+	}
+	var entries []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		lang := NormalizeLanguage(tag)
+		if lang == "" || lang == "*" {
+			continue
+		}
+		q := 1.0
+		if value, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q > 0 {
+			entries = append(entries, weighted{lang, q})
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].q > entries[j].q })
+
+	var langs []string
+	seen := map[string]bool{}
+	for _, entry := range entries {
+		if !seen[entry.lang] {
+			seen[entry.lang] = true
+			langs = append(langs, entry.lang)
+		}
+	}
+	return langs
+}
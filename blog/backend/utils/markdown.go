@@ -0,0 +1,415 @@
+package utils
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// RenderMarkdown 将Markdown渲染为HTML，用于静态站点导出等需要在服务端渲染的场景
+// 支持常用语法：标题、段落、强调、删除线、行内代码、代码块、引用、列表、表格、分割线、链接和图片；
+// 原始HTML会被转义；标题带有由 HeadingAnchor 生成的id属性，可作为目录锚点
+func RenderMarkdown(source string) string {
+	source = strings.ReplaceAll(source, "\r\n", "\n")
+	source = strings.ReplaceAll(source, "\t", "    ")
+
+	var b strings.Builder
+	renderBlocks(&b, strings.Split(source, "\n"))
+	return addHeadingIDs(b.String())
+}
+
+var (
+	mdHeading    = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	mdRule       = regexp.MustCompile(`^ {0,3}(?:(?:\* *){3,}|(?:- *){3,}|(?:_ *){3,})$`)
+	mdFence      = regexp.MustCompile("^ {0,3}(```+|~~~+)\\s*([\\w+#.-]*)")
+	mdUnordered  = regexp.MustCompile(`^( {0,3})([-*+])\s+(.*)$`)
+	mdOrdered    = regexp.MustCompile(`^( {0,3})(\d{1,9})[.)]\s+(.*)$`)
+	mdTableDelim = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+	mdQuote      = regexp.MustCompile(`^ {0,3}> ?(.*)$`)
+	mdEmphasis   = []struct {
+		re   *regexp.Regexp
+		repl string
+	}{
+		{regexp.MustCompile(`\*\*(\S(?:.*?\S)?)\*\*`), "<strong>$1</strong>"},
+		{regexp.MustCompile(`__(\S(?:.*?\S)?)__`), "<strong>$1</strong>"},
+		{regexp.MustCompile(`\*(\S(?:.*?\S)?)\*`), "<em>$1</em>"},
+		{regexp.MustCompile(`(^|[^\w])_(\S(?:.*?\S)?)_([^\w]|$)`), "$1<em>$2</em>$3"},
+		{regexp.MustCompile(`~~(\S(?:.*?\S)?)~~`), "<del>$1</del>"},
+	}
+)
+
+// 渲染块级元素
+func renderBlocks(b *strings.Builder, lines []string) {
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			i++
+
+		case mdFence.MatchString(line):
+			match := mdFence.FindStringSubmatch(line)
+			fence := match[1]
+			var code []string
+			i++
+			for i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), fence) {
+				code = append(code, lines[i])
+				i++
+			}
+			i++ // 跳过结束标记
+			if match[2] != "" {
+				b.WriteString(`<pre><code class="language-` + html.EscapeString(match[2]) + `">`)
+			} else {
+				b.WriteString("<pre><code>")
+			}
+			b.WriteString(html.EscapeString(strings.Join(code, "\n")))
+			b.WriteString("</code></pre>\n")
+
+		case mdHeading.MatchString(trimmed):
+			match := mdHeading.FindStringSubmatch(trimmed)
+			level := strconv.Itoa(len(match[1]))
+			b.WriteString("<h" + level + ">" + renderInline(match[2]) + "</h" + level + ">\n")
+			i++
+
+		case mdRule.MatchString(line):
+			b.WriteString("<hr>\n")
+			i++
+
+		case mdQuote.MatchString(line):
+			var quoted []string
+			for i < len(lines) && strings.TrimSpace(lines[i]) != "" {
+				if match := mdQuote.FindStringSubmatch(lines[i]); match != nil {
+					quoted = append(quoted, match[1])
+				} else {
+					quoted = append(quoted, lines[i])
+				}
+				i++
+			}
+			b.WriteString("<blockquote>\n")
+			renderBlocks(b, quoted)
+			b.WriteString("</blockquote>\n")
+
+		case mdUnordered.MatchString(line) || mdOrdered.MatchString(line):
+			i = renderList(b, lines, i)
+
+		case strings.HasPrefix(line, "    "):
+			var code []string
+			for i < len(lines) && (strings.HasPrefix(lines[i], "    ") || strings.TrimSpace(lines[i]) == "") {
+				code = append(code, strings.TrimPrefix(lines[i], "    "))
+				i++
+			}
+			b.WriteString("<pre><code>")
+			b.WriteString(html.EscapeString(strings.TrimRight(strings.Join(code, "\n"), "\n")))
+			b.WriteString("</code></pre>\n")
+
+		case strings.Contains(line, "|") && i+1 < len(lines) && mdTableDelim.MatchString(lines[i+1]):
+			i = renderTable(b, lines, i)
+
+		default:
+			var paragraph []string
+			for i < len(lines) && isParagraphLine(lines, i) {
+				paragraph = append(paragraph, lines[i])
+				i++
+			}
+			// 段落后紧跟 === 时为Setext一级标题
+			if i < len(lines) && len(paragraph) > 0 {
+				if underline := strings.TrimSpace(lines[i]); underline != "" && strings.Trim(underline, "=") == "" {
+					b.WriteString("<h1>" + renderInline(strings.Join(paragraph, " ")) + "</h1>\n")
+					i++
+					continue
+				}
+			}
+			if len(paragraph) == 0 {
+				// 无法识别的行按普通文本处理，避免死循环
+				paragraph = append(paragraph, line)
+				i++
+			}
+			b.WriteString("<p>" + renderParagraph(paragraph) + "</p>\n")
+		}
+	}
+}
+
+// 该行是否可以作为段落的一部分
+func isParagraphLine(lines []string, i int) bool {
+	line := lines[i]
+	if strings.TrimSpace(line) == "" {
+		return false
+	}
+	return !mdFence.MatchString(line) &&
+		!mdHeading.MatchString(strings.TrimSpace(line)) &&
+		!mdRule.MatchString(line) &&
+		!mdQuote.MatchString(line) &&
+		!mdUnordered.MatchString(line) &&
+		!mdOrdered.MatchString(line) &&
+		strings.Trim(strings.TrimSpace(line), "=") != ""
+}
+
+// 渲染段落，行尾两个空格或反斜杠表示换行
+func renderParagraph(lines []string) string {
+	parts := make([]string, len(lines))
+	for i, line := range lines {
+		hardBreak := strings.HasSuffix(line, "  ") || strings.HasSuffix(line, "\\")
+		line = strings.TrimSpace(strings.TrimSuffix(line, "\\"))
+		parts[i] = renderInline(line)
+		if hardBreak && i < len(lines)-1 {
+			parts[i] += "<br>"
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// 渲染列表，列表项内容按缩进收集后递归渲染，返回列表结束后的行号
+func renderList(b *strings.Builder, lines []string, i int) int {
+	ordered := mdOrdered.MatchString(lines[i]) && !mdUnordered.MatchString(lines[i])
+	tag := "ul"
+	if ordered {
+		tag = "ol"
+		if start := mdOrdered.FindStringSubmatch(lines[i])[2]; start != "1" {
+			n, _ := strconv.Atoi(start)
+			b.WriteString(`<ol start="` + strconv.Itoa(n) + `">` + "\n")
+		} else {
+			b.WriteString("<ol>\n")
+		}
+	} else {
+		b.WriteString("<ul>\n")
+	}
+
+	for i < len(lines) {
+		var match []string
+		if ordered {
+			match = mdOrdered.FindStringSubmatch(lines[i])
+		} else {
+			match = mdUnordered.FindStringSubmatch(lines[i])
+		}
+		if match == nil {
+			break
+		}
+		indent := len(match[1]) + len(match[2]) + 1
+		if ordered {
+			indent++
+		}
+
+		item := []string{match[3]}
+		i++
+		loose := false
+		for i < len(lines) {
+			line := lines[i]
+			if strings.TrimSpace(line) == "" {
+				// 空行后仍有缩进内容时属于同一列表项
+				if i+1 < len(lines) && leadingSpaces(lines[i+1]) >= indent {
+					item = append(item, "")
+					loose = true
+					i++
+					continue
+				}
+				break
+			}
+			if leadingSpaces(line) >= indent {
+				item = append(item, line[indent:])
+			} else if leadingSpaces(line) >= 2 && (mdUnordered.MatchString(strings.TrimLeft(line, " ")) || mdOrdered.MatchString(strings.TrimLeft(line, " "))) {
+				item = append(item, strings.TrimLeft(line, " "))
+			} else if mdUnordered.MatchString(line) || mdOrdered.MatchString(line) || !isParagraphLine(lines, i) {
+				break
+			} else {
+				item = append(item, line) // 惰性续行
+			}
+			i++
+		}
+
+		b.WriteString("<li>")
+		if loose {
+			// 松散列表的内容按块级元素渲染
+			b.WriteString("\n")
+			renderBlocks(b, item)
+		} else {
+			// 紧凑列表开头的文本不包裹段落，之后的嵌套列表等按块级元素渲染
+			first := 1
+			for first < len(item) && isPlainLine(item[first]) {
+				first++
+			}
+			b.WriteString(renderParagraph(item[:first]))
+			if first < len(item) {
+				b.WriteString("\n")
+				renderBlocks(b, item[first:])
+			}
+		}
+		b.WriteString("</li>\n")
+
+		// 空行分隔的同类列表项
+		if i < len(lines) && strings.TrimSpace(lines[i]) == "" && i+1 < len(lines) {
+			next := lines[i+1]
+			if (ordered && mdOrdered.MatchString(next)) || (!ordered && mdUnordered.MatchString(next)) {
+				i++
+			}
+		}
+	}
+
+	b.WriteString("</" + tag + ">\n")
+	return i
+}
+
+// 是否为普通文本行（非块级元素的开始）
+func isPlainLine(line string) bool {
+	return strings.TrimSpace(line) != "" &&
+		!mdFence.MatchString(line) &&
+		!mdQuote.MatchString(line) &&
+		!mdUnordered.MatchString(line) &&
+		!mdOrdered.MatchString(line) &&
+		!mdHeading.MatchString(strings.TrimSpace(line))
+}
+
+func leadingSpaces(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// 渲染GFM表格，返回表格结束后的行号
+func renderTable(b *strings.Builder, lines []string, i int) int {
+	header := splitTableRow(lines[i])
+	delims := splitTableRow(lines[i+1])
+	aligns := make([]string, len(delims))
+	for j, delim := range delims {
+		left, right := strings.HasPrefix(delim, ":"), strings.HasSuffix(delim, ":")
+		switch {
+		case left && right:
+			aligns[j] = "center"
+		case right:
+			aligns[j] = "right"
+		case left:
+			aligns[j] = "left"
+		}
+	}
+
+	cell := func(tag string, j int, content string) string {
+		attr := ""
+		if j < len(aligns) && aligns[j] != "" {
+			attr = ` style="text-align:` + aligns[j] + `"`
+		}
+		return "<" + tag + attr + ">" + renderInline(content) + "</" + tag + ">"
+	}
+
+	b.WriteString("<table>\n<thead>\n<tr>")
+	for j, content := range header {
+		b.WriteString(cell("th", j, content))
+	}
+	b.WriteString("</tr>\n</thead>\n<tbody>\n")
+
+	i += 2
+	for i < len(lines) && strings.TrimSpace(lines[i]) != "" && strings.Contains(lines[i], "|") {
+		b.WriteString("<tr>")
+		for j, content := range splitTableRow(lines[i]) {
+			if j < len(header) {
+				b.WriteString(cell("td", j, content))
+			}
+		}
+		b.WriteString("</tr>\n")
+		i++
+	}
+
+	b.WriteString("</tbody>\n</table>\n")
+	return i
+}
+
+func splitTableRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	line = strings.TrimSuffix(line, "|")
+
+	// 忽略转义的竖线
+	cells := strings.Split(strings.ReplaceAll(line, `\|`, "\x00"), "|")
+	for i, cell := range cells {
+		cells[i] = strings.ReplaceAll(strings.TrimSpace(cell), "\x00", "|")
+	}
+	return cells
+}
+
+var (
+	mdCodeSpan  = regexp.MustCompile("(`+)(.+?)(`+)")
+	mdImage     = regexp.MustCompile(`!\[([^\]]*)\]\(\s*<?([^\s)>]*)>?(?:\s+"([^"]*)")?\s*\)`)
+	mdLink      = regexp.MustCompile(`\[((?:[^\[\]]|\[[^\]]*\])*)\]\(\s*<?([^\s)>]*)>?(?:\s+"([^"]*)")?\s*\)`)
+	mdAutoLink  = regexp.MustCompile(`<((?:https?|mailto):[^\s<>]+)>`)
+	mdEscape    = regexp.MustCompile("\\\\([\\\\`*_{}\\[\\]()#+\\-.!|~<>])")
+	mdHolder    = regexp.MustCompile("\x00(\\d+)\x00")
+	safeURLHead = regexp.MustCompile(`^(?i)(https?:|mailto:|/|#|\./|\.\./|[^:/?#]*(?:[/?#]|$))`)
+)
+
+// 渲染行内元素：先用占位符保护代码、链接和图片，转义HTML后再处理强调语法
+func renderInline(text string) string {
+	var holders []string
+	return restoreHolders(renderInlineHeld(text, &holders), holders)
+}
+
+func renderInlineHeld(text string, holders *[]string) string {
+	hold := func(s string) string {
+		*holders = append(*holders, s)
+		return "\x00" + strconv.Itoa(len(*holders)-1) + "\x00"
+	}
+
+	text = mdCodeSpan.ReplaceAllStringFunc(text, func(m string) string {
+		match := mdCodeSpan.FindStringSubmatch(m)
+		if len(match[1]) != len(match[3]) {
+			return m
+		}
+		return hold("<code>" + html.EscapeString(strings.TrimSpace(match[2])) + "</code>")
+	})
+
+	text = mdEscape.ReplaceAllStringFunc(text, func(m string) string {
+		return hold(html.EscapeString(m[1:]))
+	})
+
+	text = mdImage.ReplaceAllStringFunc(text, func(m string) string {
+		match := mdImage.FindStringSubmatch(m)
+		tag := `<img src="` + html.EscapeString(safeURL(match[2])) + `" alt="` + html.EscapeString(match[1]) + `"`
+		if match[3] != "" {
+			tag += ` title="` + html.EscapeString(match[3]) + `"`
+		}
+		return hold(tag + ">")
+	})
+
+	text = mdLink.ReplaceAllStringFunc(text, func(m string) string {
+		match := mdLink.FindStringSubmatch(m)
+		tag := `<a href="` + html.EscapeString(safeURL(match[2])) + `"`
+		if match[3] != "" {
+			tag += ` title="` + html.EscapeString(match[3]) + `"`
+		}
+		return hold(tag + ">" + renderInlineHeld(match[1], holders) + "</a>")
+	})
+
+	text = mdAutoLink.ReplaceAllStringFunc(text, func(m string) string {
+		url := m[1 : len(m)-1]
+		return hold(`<a href="` + html.EscapeString(url) + `">` + html.EscapeString(url) + "</a>")
+	})
+
+	text = html.EscapeString(text)
+	for _, emphasis := range mdEmphasis {
+		text = emphasis.re.ReplaceAllString(text, emphasis.repl)
+	}
+	return text
+}
+
+// 还原占位符（占位符中可能嵌套其他占位符）
+func restoreHolders(text string, holders []string) string {
+	for strings.Contains(text, "\x00") {
+		replaced := mdHolder.ReplaceAllStringFunc(text, func(m string) string {
+			index, _ := strconv.Atoi(strings.Trim(m, "\x00"))
+			if index < len(holders) {
+				return holders[index]
+			}
+			return ""
+		})
+		if replaced == text {
+			break
+		}
+		text = replaced
+	}
+	return text
+}
+
+// 过滤javascript:等不安全的链接
+func safeURL(url string) string {
+	if safeURLHead.MatchString(url) {
+		return url
+	}
+	return "#"
+}
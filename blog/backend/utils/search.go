@@ -0,0 +1,150 @@
+package utils
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+// IsCJK 判断字符是否为中日韩文字（汉字、平假名、片假名和韩文）
+func IsCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r)
+}
+
+// SearchTerms 将搜索词拆分为检索词条：连续的中日韩字符拆成二元词组，其余按非字母数字字符切分
+func SearchTerms(text string) []string {
+	var terms []string
+	seen := make(map[string]bool)
+	add := func(term string) {
+		term = strings.ToLower(term)
+		if term != "" && !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+
+	var word []rune
+	var cjk []rune
+	flushWord := func() {
+		add(string(word))
+		word = word[:0]
+	}
+	flushCJK := func() {
+		if len(cjk) == 1 {
+			add(string(cjk))
+		}
+		for i := 0; i+1 < len(cjk); i++ {
+			add(string(cjk[i : i+2]))
+		}
+		cjk = cjk[:0]
+	}
+
+	for _, r := range text {
+		switch {
+		case IsCJK(r):
+			flushWord()
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushCJK()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushCJK()
+		}
+	}
+	flushWord()
+	flushCJK()
+
+	return terms
+}
+
+// BuildTSQuery 将检索词条拼接为to_tsquery可用的表达式，prefix为true时最后一个词条按前缀匹配
+func BuildTSQuery(terms []string, prefix bool) string {
+	parts := make([]string, 0, len(terms))
+	for i, term := range terms {
+		part := "'" + strings.ReplaceAll(term, "'", "''") + "'"
+		if prefix && i == len(terms)-1 {
+			part += ":*"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, " & ")
+}
+
+// HighlightSnippet 截取文本中第一个命中词附近的片段，并用<mark>标签包裹命中词
+func HighlightSnippet(text string, terms []string, maxLen int) string {
+	runes := []rune(text)
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	// 找到第一个命中位置
+	first := -1
+	for _, term := range terms {
+		if idx := runeIndex(lower, []rune(term), 0); idx >= 0 && (first < 0 || idx < first) {
+			first = idx
+		}
+	}
+
+	// 以命中位置为中心截取片段
+	start := 0
+	if first > maxLen/3 {
+		start = first - maxLen/3
+	}
+	end := start + maxLen
+	if end > len(runes) {
+		end = len(runes)
+	}
+
+	// 在片段内标记所有命中词
+	marked := make([]bool, end-start)
+	for _, term := range terms {
+		termRunes := []rune(term)
+		for idx := runeIndex(lower[:end], termRunes, start); idx >= 0; idx = runeIndex(lower[:end], termRunes, idx+1) {
+			for i := idx; i < idx+len(termRunes) && i < end; i++ {
+				marked[i-start] = true
+			}
+		}
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("...")
+	}
+	for i := start; i < end; i++ {
+		if marked[i-start] && (i == start || !marked[i-start-1]) {
+			b.WriteString("<mark>")
+		}
+		b.WriteString(html.EscapeString(string(runes[i])))
+		if marked[i-start] && (i == end-1 || !marked[i-start+1]) {
+			b.WriteString("</mark>")
+		}
+	}
+	if end < len(runes) {
+		b.WriteString("...")
+	}
+
+	return b.String()
+}
+
+// runeIndex 从from位置开始查找子串，返回字符下标，未找到返回-1
+func runeIndex(s, sub []rune, from int) int {
+	if len(sub) == 0 {
+		return -1
+	}
+	for i := from; i+len(sub) <= len(s); i++ {
+		match := true
+		for j := range sub {
+			if s[i+j] != sub[j] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	localZoneOnce sync.Once
+	localZoneName string
+	localZoneLoc  *time.Location
+)
+
+// LocalZone 返回服务器本地时区的IANA名称及对应的时区，用于让数据库按同一时区计算日期
+// 无法确定本地时区的名称时（如 /etc/localtime 不是符号链接）统一使用UTC
+func LocalZone() (string, *time.Location) {
+	localZoneOnce.Do(func() {
+		localZoneName, localZoneLoc = "UTC", time.UTC
+
+		name := time.Local.String()
+		if name == "Local" {
+			name = ""
+			if target, err := os.Readlink("/etc/localtime"); err == nil {
+				if i := strings.Index(target, "zoneinfo/"); i >= 0 {
+					name = target[i+len("zoneinfo/"):]
+				}
+			}
+		}
+		if name == "" {
+			return
+		}
+		if loc, err := time.LoadLocation(name); err == nil {
+			localZoneName, localZoneLoc = name, loc
+		}
+	})
+	return localZoneName, localZoneLoc
+}